@@ -0,0 +1,251 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// A command is a subcommand of the CLI, e.g. "records list".
+// Its flags are shared with the deprecated top-level flags of the same name.
+type command struct {
+	name  string
+	args  string // Synopsis of the positional arguments.
+	help  string
+	flags []string // Names of the top-level flags the command accepts, besides commonFlags.
+	// setup enables the command's mode based on its positional arguments.
+	setup func(args []string) error
+}
+
+// commonFlags are accepted by all commands.
+var commonFlags = []string{"config", "domain", "timeout", "log-format", "quiet", "verbose", "retries", "request-timeout"}
+
+// modeFlags are the top-level flags that select what the CLI does.
+// Using them without a command is deprecated.
+var modeFlags = []string{"print", "summary", "check-auth", "myip", "status", "sync", "import", "import-csv", "export",
+	"acme-auth", "acme-cleanup", "dyndns", "daemon", "watch"}
+
+var commands = []*command{
+	{
+		name: "dyndns",
+		help: "Sets the A (or AAAA) records of the configured subdomains to the public IP.",
+		flags: []string{"daemon", "interval", "listen", "ready-intervals", "dry-run", "ip-source", "ip", "create-missing", "family",
+			"subdomain", "record-id", "check-url", "check-timeout", "check-method", "check-follow-redirects",
+			"check-status", "force", "ip-cache-ttl", "notify-url", "ipv4-endpoint", "ttl", "notes"},
+		setup: func(args []string) error {
+			*dyndns = true
+			return noArgs(args)
+		},
+	},
+	{
+		name:  "records list",
+		help:  "Prints the records of the domain.",
+		flags: []string{"type", "name", "grep", "output"},
+		setup: func(args []string) error {
+			*printRecords = *recordType
+			if *printRecords == "" {
+				*printRecords = "all"
+			}
+			return noArgs(args)
+		},
+	},
+	{
+		name:  "records summary",
+		help:  "Prints the number of records of each type.",
+		flags: []string{"output"},
+		setup: func(args []string) error {
+			*summary = true
+			return noArgs(args)
+		},
+	},
+	{
+		name:  "records create",
+		help:  "Creates a record.",
+		flags: []string{"type", "name", "content", "ttl", "prio", "notes"},
+		setup: func(args []string) error {
+			*create = true
+			return noArgs(args)
+		},
+	},
+	{
+		name:  "records delete",
+		help:  "Deletes the record with the given -id, or all records of the given -type and -name.",
+		flags: []string{"id", "type", "name", "yes"},
+		setup: func(args []string) error {
+			*deleteRecords = true
+			return noArgs(args)
+		},
+	},
+	{
+		name:  "records watch",
+		help:  "Reports records that are added, removed, or modified, polling every -interval.",
+		flags: []string{"interval", "notify-url"},
+		setup: func(args []string) error {
+			*watch = true
+			return noArgs(args)
+		},
+	},
+	{
+		name:  "records sync",
+		args:  "FILE",
+		help:  "Creates, updates, and deletes records to match the JSON file FILE (see -sync).",
+		flags: []string{"prune", "dry-run", "ttl", "notes"},
+		setup: func(args []string) error {
+			return fileArg(args, syncFile)
+		},
+	},
+	{
+		name:  "records import",
+		args:  "FILE",
+		help:  "Creates the records of the BIND zone file FILE.",
+		flags: []string{"dry-run", "ttl", "notes"},
+		setup: func(args []string) error {
+			return fileArg(args, importFile)
+		},
+	},
+	{
+		name:  "records import-csv",
+		args:  "FILE",
+		help:  "Creates the records of the CSV file FILE (see -import-csv).",
+		flags: []string{"dry-run", "ttl", "notes"},
+		setup: func(args []string) error {
+			return fileArg(args, importCSV)
+		},
+	},
+	{
+		name: "records export",
+		args: "FILE",
+		help: "Writes the records of the domain to the BIND zone file FILE (\"-\" for stdout).",
+		setup: func(args []string) error {
+			return fileArg(args, exportFile)
+		},
+	},
+	{
+		name:  "ping",
+		help:  "Verifies the API keys and prints the public IP detected by Porkbun.",
+		flags: []string{"ipv4-endpoint"},
+		setup: func(args []string) error {
+			*checkAuth = true
+			return noArgs(args)
+		},
+	},
+	{
+		name:  "myip",
+		help:  "Prints the public IPv4 and IPv6 addresses of the host, as detected by Porkbun.",
+		flags: []string{"output"},
+		setup: func(args []string) error {
+			*myIP = true
+			return noArgs(args)
+		},
+	},
+	{
+		name:  "status",
+		help:  "Prints the status, expiry date, and auto-renew setting of the domain.",
+		flags: []string{"output"},
+		setup: func(args []string) error {
+			*status = true
+			return noArgs(args)
+		},
+	},
+	{
+		name:  "acme auth",
+		help:  "Runs as a certbot --manual-auth-hook (see -acme-auth).",
+		flags: []string{"acme-wait"},
+		setup: func(args []string) error {
+			*acmeAuth = true
+			return noArgs(args)
+		},
+	},
+	{
+		name: "acme cleanup",
+		help: "Runs as a certbot --manual-cleanup-hook (see -acme-cleanup).",
+		setup: func(args []string) error {
+			*acmeCleanup = true
+			return noArgs(args)
+		},
+	},
+}
+
+func noArgs(args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(args, " "))
+	}
+	return nil
+}
+
+func fileArg(args []string, file *string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected exactly one FILE argument")
+	}
+	*file = args[0]
+	return nil
+}
+
+// findCommand returns the command named by the first one or two args,
+// and the remaining args.
+func findCommand(args []string) (*command, []string) {
+	for _, cmd := range commands {
+		words := strings.Fields(cmd.name)
+		if len(args) >= len(words) && strings.Join(args[:len(words)], " ") == cmd.name {
+			return cmd, args[len(words):]
+		}
+	}
+	return nil, args
+}
+
+// parseArgs parses the command line. It returns true if the deprecated
+// top-level mode flags were used instead of a command.
+func parseArgs(args []string) (deprecated bool, err error) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		if err := flag.CommandLine.Parse(args); err != nil {
+			return false, err
+		}
+		if flag.NArg() > 0 {
+			return false, fmt.Errorf("unexpected arguments: %s (commands must precede flags)", strings.Join(flag.Args(), " "))
+		}
+		flag.Visit(func(f *flag.Flag) {
+			for _, m := range modeFlags {
+				if f.Name == m {
+					deprecated = true
+				}
+			}
+		})
+		return deprecated, nil
+	}
+	cmd, rest := findCommand(args)
+	if cmd == nil {
+		return false, fmt.Errorf("unknown command %q, run with -help to list commands", args[0])
+	}
+	fs := flag.NewFlagSet("porkbun "+cmd.name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: porkbun %s\n\n%s\n\nFlags:\n", strings.TrimSpace(cmd.name+" [flags] "+cmd.args), cmd.help)
+		fs.PrintDefaults()
+	}
+	for _, name := range append(cmd.flags, commonFlags...) {
+		f := flag.Lookup(name)
+		fs.Var(f.Value, f.Name, f.Usage)
+	}
+	if err := fs.Parse(rest); err != nil {
+		return false, err
+	}
+	return false, cmd.setup(fs.Args())
+}
+
+// usage prints the top-level usage, listing commands and the deprecated flags.
+func usage() {
+	w := flag.CommandLine.Output()
+	fmt.Fprintf(w, "Usage: porkbun COMMAND [flags]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-16s %s\n", cmd.name, cmd.help)
+	}
+	fmt.Fprintf(w, "\nRun \"porkbun COMMAND -help\" for the flags of a command.\n\n"+
+		"Deprecated: instead of a command, the following flags can be used directly.\n"+
+		"They will be removed in a future release.\n\n")
+	flag.PrintDefaults()
+}
+
+func init() {
+	flag.Usage = usage
+	flag.CommandLine.Init(os.Args[0], flag.ContinueOnError)
+}
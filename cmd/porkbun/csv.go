@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/dnswlt/porkbun/pkg/api"
+)
+
+// csvRecord is a record read from an -import-csv file.
+type csvRecord struct {
+	line int // Line number in the file, for error messages.
+	req  api.UpdateRequest
+	err  error // Non-nil if the row is invalid.
+}
+
+// csvColumns are the columns of an -import-csv file. Columns not marked
+// as optional are required.
+var csvColumns = []struct {
+	name     string
+	optional bool
+}{{"name", false}, {"type", false}, {"content", false}, {"ttl", true}, {"prio", true}}
+
+// readCSVRecords reads records from a CSV file whose header row names the columns
+// name, type, content, and optionally ttl and prio, in any order. Invalid rows are
+// returned with a non-nil err, so that all of them can be reported at once.
+func readCSVRecords(r io.Reader) ([]csvRecord, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read header row: %w", err)
+	}
+	index := make(map[string]int)
+	for i, col := range header {
+		index[strings.ToLower(strings.TrimSpace(col))] = i
+	}
+	for _, col := range csvColumns {
+		if _, ok := index[col.name]; !ok && !col.optional {
+			return nil, fmt.Errorf("header row has no %q column", col.name)
+		}
+	}
+	field := func(row []string, col string) string {
+		i, ok := index[col]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	var records []csvRecord
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			pe, ok := err.(*csv.ParseError)
+			if !ok {
+				return nil, err
+			}
+			records = append(records, csvRecord{line: pe.StartLine, err: pe.Err})
+			continue
+		}
+		line, _ := cr.FieldPos(0)
+		rec := csvRecord{
+			line: line,
+			req: api.UpdateRequest{
+				Name:    field(row, "name"),
+				Type:    strings.ToUpper(field(row, "type")),
+				Content: field(row, "content"),
+				TTL:     field(row, "ttl"),
+				Prio:    field(row, "prio"),
+			},
+		}
+		switch {
+		case !api.ValidRecordType(rec.req.Type):
+			rec.err = fmt.Errorf("invalid type %q", rec.req.Type)
+		case rec.req.Content == "":
+			rec.err = fmt.Errorf("empty content")
+		}
+		records = append(records, rec)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/dnswlt/porkbun/pkg/porkbun"
+)
+
+// Exit codes of the CLI.
+const (
+	exitFailure      = 1 // Any other error.
+	exitConfig       = 2 // Invalid flags or config.
+	exitAuth         = 3 // Invalid API keys.
+	exitNetwork      = 4 // Network errors and transient API errors; retrying may help.
+	exitUpdateNeeded = 5 // In -dry-run mode, an update would have been made.
+)
+
+// cliError is an error that main logs with its attributes before it exits with code.
+type cliError struct {
+	msg    string
+	args   []any
+	code   int
+	logged bool // msg was logged already
+}
+
+func (e *cliError) Error() string {
+	return e.msg
+}
+
+// errUpdateNeeded is returned in -dry-run mode if an update would have been made.
+var errUpdateNeeded = &cliError{msg: "update needed", code: exitUpdateNeeded, logged: true}
+
+// failure returns a cliError for msg and its log attributes.
+// The exit code is derived from the "err" attribute, if any.
+func failure(msg string, args ...any) error {
+	code := exitFailure
+	for i := 0; i+1 < len(args); i += 2 {
+		if err, ok := args[i+1].(error); ok && args[i] == "err" {
+			code = exitCode(err)
+		}
+	}
+	return &cliError{msg: msg, args: args, code: code}
+}
+
+// configError returns a cliError for msg and its log attributes with exit code exitConfig.
+func configError(msg string, args ...any) error {
+	return &cliError{msg: msg, args: args, code: exitConfig}
+}
+
+// loggedError returns a cliError for err, which was logged already.
+func loggedError(err error) error {
+	return &cliError{msg: err.Error(), code: exitCode(err), logged: true}
+}
+
+// exitCode returns the exit code for err.
+func exitCode(err error) int {
+	var apiErr *porkbun.APIError
+	var netErr net.Error
+	var nonJSONErr *porkbun.NonJSONError
+	switch {
+	case errors.As(err, &nonJSONErr):
+		return exitNetwork
+	case errors.As(err, &apiErr):
+		switch {
+		case apiErr.HTTPStatus == http.StatusUnauthorized, apiErr.HTTPStatus == http.StatusForbidden,
+			strings.Contains(strings.ToLower(apiErr.Message), "api key"):
+			return exitAuth
+		case apiErr.HTTPStatus == http.StatusTooManyRequests, apiErr.HTTPStatus >= 500:
+			return exitNetwork
+		}
+	case errors.As(err, &netErr), errors.Is(err, context.DeadlineExceeded):
+		return exitNetwork
+	}
+	return exitFailure
+}
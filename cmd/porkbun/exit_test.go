@@ -0,0 +1,36 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/dnswlt/porkbun/pkg/porkbun"
+)
+
+func TestExitCodeWrapped(t *testing.T) {
+	authErr := &porkbun.APIError{Status: "ERROR", Message: "Invalid API key. (002)", HTTPStatus: http.StatusOK}
+	netErr := fmt.Errorf("POST failed: %w", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")})
+	// As returned by updateFamily for multiple failed subdomains.
+	joined := func(errs ...error) error {
+		return fmt.Errorf("failed to update %d of %d records: %w", len(errs), 3, errors.Join(errs...))
+	}
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"auth", joined(fmt.Errorf("www.example.com: %w", authErr)), exitAuth},
+		{"network", joined(fmt.Errorf("www.example.com: %w", netErr)), exitNetwork},
+		{"network and other", joined(errors.New("no A record"), fmt.Errorf("home.example.com: %w", netErr)), exitNetwork},
+		{"dns lookup", fmt.Errorf("DNS lookup failed: %w", &net.DNSError{Err: "timeout", IsTimeout: true}), exitNetwork},
+		{"other", joined(errors.New("no A record")), exitFailure},
+	}
+	for _, tc := range tests {
+		if got := exitCode(tc.err); got != tc.want {
+			t.Errorf("exitCode(%s) = %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// ipCacheEntry is the last IP set for a record.
+type ipCacheEntry struct {
+	IP      string    `json:"ip"`
+	Updated time.Time `json:"updated"`
+}
+
+// ipCache records the last IP that -dyndns set (or found to be current) for each record,
+// so that runs without a change can skip all DNS lookups and API calls.
+type ipCache struct {
+	path    string
+	ttl     time.Duration
+	entries map[string]ipCacheEntry
+}
+
+// loadIPCache reads the cache file at path. A missing file yields an empty cache.
+func loadIPCache(path string, ttl time.Duration) (*ipCache, error) {
+	c := &ipCache{path: path, ttl: ttl, entries: make(map[string]ipCacheEntry)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &c.entries); err != nil {
+		// A corrupt cache is as good as no cache.
+		return c, nil
+	}
+	return c, nil
+}
+
+func ipCacheKey(recordType, domain string) string {
+	return recordType + " " + domain
+}
+
+// fresh returns true if ip was cached for the record less than ttl ago.
+func (c *ipCache) fresh(recordType, domain, ip string) bool {
+	e, ok := c.entries[ipCacheKey(recordType, domain)]
+	return ok && e.IP == ip && time.Since(e.Updated) < c.ttl
+}
+
+func (c *ipCache) set(recordType, domain, ip string) {
+	c.entries[ipCacheKey(recordType, domain)] = ipCacheEntry{IP: ip, Updated: time.Now()}
+}
+
+func (c *ipCache) invalidate(recordType, domain string) {
+	delete(c.entries, ipCacheKey(recordType, domain))
+}
+
+// save writes the cache file.
+func (c *ipCache) save() error {
+	data, err := json.MarshalIndent(c.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, data, 0o600)
+}
@@ -1,19 +1,31 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
+	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net"
 	"net/http"
 	"os"
-	"path"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/dnswlt/porkbun/pkg/api"
 	"github.com/dnswlt/porkbun/pkg/porkbun"
+	"github.com/dnswlt/porkbun/pkg/porkbunacme"
+	"github.com/dnswlt/porkbun/pkg/publicip"
+	"github.com/dnswlt/porkbun/pkg/zone"
 )
 
 var (
@@ -21,34 +33,245 @@ var (
 		"Comma-separated list of DNS records types (A, AAAA, CNAME, TXT, etc.) to print.\n"+
 			"Set to \"all\" to print all records.")
 
+	create = flag.Bool("create", false,
+		"If true, creates the record given by -type, -name, -content, -ttl, and -prio,\n"+
+			"and prints its ID. Same as the \"records create\" command.")
+
+	deleteRecords = flag.Bool("delete", false,
+		"If true, deletes the record with the given -id, or all records of the given -type and -name,\n"+
+			"and prints the number of deleted records. Same as the \"records delete\" command.")
+
+	nameFilter = flag.String("name", "",
+		"The record name. If set, -print only prints records of this name and its subdomains.\n"+
+			"The name can be a subdomain (\"home\") or fully qualified (\"home.example.com.\").\n"+
+			"For -create and -delete, the subdomain of the record.")
+
+	recordType = flag.String("type", "",
+		"The record type (A, AAAA, CNAME, TXT, etc.) for -create and -delete.\n"+
+			"For \"records list\", a comma-separated list of types to print (default: all).")
+
+	content = flag.String("content", "",
+		"The content of the record for -create, e.g. an IP address.")
+
+	prio = flag.String("prio", "",
+		"The priority of the record for -create, for record types that support it.")
+
+	recordID = flag.String("id", "",
+		"The ID of the record for -delete.")
+
+	yes = flag.Bool("yes", false,
+		"If true, -delete deletes records without asking for confirmation.\n"+
+			"Without -yes, -delete prompts if stdin is a terminal and fails otherwise.")
+
+	grep = flag.String("grep", "",
+		"If set, -print only prints records that contain this string in any field, ignoring case.")
+
+	output = flag.String("output", "text",
+		"The output format of -print, -summary, -myip, and -status: \"text\" logs the records, \"json\" writes them as JSON to stdout.\n"+
+			"In \"json\" mode, informational log output is suppressed.")
+
+	summary = flag.Bool("summary", false,
+		"If true, prints the number of records of each type, e.g. \"A: 12, AAAA: 8, TXT: 3\".")
+
+	checkAuth = flag.Bool("check-auth", false,
+		"If true, verifies the API keys by calling Porkbun's ping API and prints the detected IP.\n"+
+			"Exits with a non-zero status if the keys are invalid. -daemon mode always does this on startup.")
+
+	myIP = flag.Bool("myip", false,
+		"If true, prints the public IPv4 and IPv6 addresses of the host, as detected by Porkbun.\n"+
+			"A missing address is not an error, unless both are missing.")
+
+	status = flag.Bool("status", false,
+		"If true, prints the status, expiry date, and auto-renew setting of the domain.\n"+
+			"Exits with a non-zero status if the domain is not in the account.")
+
+	syncFile = flag.String("sync", "",
+		"Path to a JSON or YAML (.yaml, .yml) file with the desired DNS records of the domain.\n"+
+			"The file contains a list of objects with the fields name, type, content, and\n"+
+			"optionally ttl, prio, and notes, where name is the subdomain. Records are created,\n"+
+			"updated, and deleted to match the file. The plan is printed before applying it,\n"+
+			"colorized if stdout is a terminal. With -dry-run, the plan is only printed.")
+
+	prune = flag.Bool("prune", false,
+		"If true, -sync also deletes records whose name and type do not occur in the file.")
+
+	importFile = flag.String("import", "",
+		"Path to a BIND zone file whose records are created in the domain.")
+
+	importCSV = flag.String("import-csv", "",
+		"Path to a CSV file whose records are created in the domain. The header row names the\n"+
+			"columns name, type, content, and optionally ttl and prio. Failed rows are logged\n"+
+			"with their line numbers.")
+
+	exportFile = flag.String("export", "",
+		"Path of a BIND zone file to which the current records of the domain are written.\n"+
+			"Use \"-\" to write to stdout.")
+
+	acmeAuth = flag.Bool("acme-auth", false,
+		"If true, runs as a certbot --manual-auth-hook: creates the _acme-challenge TXT record\n"+
+			"for $CERTBOT_DOMAIN with the value $CERTBOT_VALIDATION.")
+
+	acmeCleanup = flag.Bool("acme-cleanup", false,
+		"If true, runs as a certbot --manual-cleanup-hook: deletes the _acme-challenge TXT records\n"+
+			"for $CERTBOT_DOMAIN.")
+
+	acmeWait = flag.Duration("acme-wait", 0,
+		"The maximum time -acme-auth waits until all authoritative nameservers serve the TXT record.\n"+
+			"Set to 0 to return without waiting.")
+
 	dyndns = flag.Bool("dyndns", false,
 		"If true, runs in dyndns mode:\n"+
 			"* obtains the current public IP of the host it is running on\n"+
-			"* sets that IP address as the A record of the configured domain")
+			"* sets that IP address as the A (or AAAA, see -family) record of the configured domain")
+
+	daemon = flag.Bool("daemon", false,
+		"If true, keeps running and performs the -dyndns update every -interval.\n"+
+			"Implies -dyndns. Stops gracefully on SIGINT or SIGTERM. On SIGUSR1, logs the last\n"+
+			"detected IPs, the number of runs and updates, and the time and error of the last run.")
+
+	listen = flag.String("listen", "",
+		"An optional address, e.g. \":8080\", on which -daemon mode serves health endpoints:\n"+
+			"/healthz succeeds while the process is running, /readyz succeeds if the last\n"+
+			"successful update was at most -ready-intervals intervals ago.")
+
+	readyIntervals = flag.Int("ready-intervals", 3,
+		"The number of -interval periods after the last successful update until /readyz fails.")
+
+	interval = flag.Duration("interval", 5*time.Minute,
+		"The interval between updates in -daemon mode, and between polls in -watch mode.")
+
+	watch = flag.Bool("watch", false,
+		"If true, keeps running and retrieves the records of the domain every -interval,\n"+
+			"logging (and sending to -notify-url) all records that were added, removed, or modified.\n"+
+			"Stops gracefully on SIGINT or SIGTERM.")
+
+	dryRun = flag.Bool("dry-run", false,
+		"If true, -dyndns, -import, -import-csv, and -sync modes only log the changes they would make, without changing any records.\n"+
+			fmt.Sprintf("In -dyndns mode, exits with status %d if an update would have been made.", exitUpdateNeeded))
+
+	ipSource = flag.String("ip-source", "porkbun",
+		"Comma-separated list of providers that -dyndns mode uses to determine the public IP.\n"+
+			"Providers are tried in order until one succeeds. Valid providers are:\n"+
+			"porkbun (the Porkbun ping API), ipify, ifconfig.me, stun (Google's STUN server).")
+
+	fixedIP = flag.String("ip", "",
+		"The IP address to set in -dyndns mode, instead of detecting the public IP.\n"+
+			"Must be an address of the -family to update.")
+
+	createMissing = flag.Bool("create-missing", false,
+		"If true, -dyndns mode creates the A (or AAAA) record if it does not exist yet.\n"+
+			"Otherwise, a missing record is an error.")
+
+	family = flag.String("family", "ipv4",
+		"The IP address family to update in -dyndns mode:\n"+
+			"\"ipv4\" updates the A record, \"ipv6\" updates the AAAA record,\n"+
+			"\"both\" updates each of them independently.")
+
+	ddRecordID = flag.String("record-id", "",
+		"The ID of a single A (or AAAA) record that -dyndns mode updates, instead of all records\n"+
+			"of the -subdomain, e.g. to update one of multiple round-robin records.\n"+
+			"Cannot be combined with -subdomain, -create-missing, or -family both.")
 
 	ddSubdomain = flag.String("subdomain", "",
-		"The subdomain to update in -dyndns mode. Leave empty to update the root domain.")
+		"Comma-separated list of subdomains to update in -dyndns mode, e.g. \"home\" or \"home.example.com\".\n"+
+			"Leave empty to update the root domain. Wildcard names like \"*\" cannot be resolved,\n"+
+			"so their records are retrieved from Porkbun on each run; use -check-url to avoid that.")
 
 	ddCheckURL = flag.String("check-url", "",
 		"An optional URL that -dyndns mode uses to determine if any DNS update is needed.\n"+
-			"If the -check-url is available (a GET request returns any http status code),\n"+
-			"then no DNS records will be updated.")
+			"If the -check-url is available (a -check-method request returns any http status code,\n"+
+			"or one of the -check-status codes), then no DNS records will be updated.")
+
+	checkTimeout = flag.Duration("check-timeout", 5*time.Second,
+		"Timeout of the -check-url request.")
+
+	checkMethod = flag.String("check-method", "GET",
+		"HTTP method of the -check-url request: \"GET\" or \"HEAD\".")
+
+	checkFollowRedirects = flag.Bool("check-follow-redirects", false,
+		"If true, the -check-url request follows redirects and checks the status of the final response.")
+
+	checkStatus = flag.String("check-status", "",
+		"Comma-separated list of HTTP status codes (e.g. \"200,204\") for which the -check-url counts\n"+
+			"as available. Leave empty to accept any status code.")
+
+	force = flag.Bool("force", false,
+		"If true, -dyndns mode always updates the records, skipping the -check-url,\n"+
+			"DNS lookup, existing record, and -ip-cache-ttl checks.")
+
+	ipCacheTTL = flag.Duration("ip-cache-ttl", 0,
+		"If positive, -dyndns mode caches the IP of each record in a file next to the config file.\n"+
+			"Records whose cached IP is younger than this TTL and matches the public IP are not\n"+
+			"looked up or updated. Set to 0 to disable the cache.")
+
+	notifyURL = flag.String("notify-url", "",
+		"An optional URL to which -dyndns mode POSTs a JSON notification whenever it changes a record.\n"+
+			"The payload has the fields domain, old_ip, new_ip, and timestamp.\n"+
+			"In -watch mode, the payload has the fields domain, change (added, removed, or modified),\n"+
+			"old, new, and timestamp.")
+
+	ttl = flag.Int("ttl", 0,
+		fmt.Sprintf("The TTL in seconds of created and edited records. Must be at least %d.\n", porkbun.MinTTL)+
+			"Records with an explicit TTL, e.g. in -import or -sync files, keep their TTL.\n"+
+			"Set to 0 to use Porkbun's default TTL.")
+
+	notes = flag.String("notes", "",
+		"The notes of created and edited records, e.g. \"managed by porkbun\".\n"+
+			"Records with explicit notes, e.g. in -sync files, keep their notes.")
+
+	domainFlag = flag.String("domain", "",
+		"The domain to manage, if the config file lists multiple domains.\n"+
+			"Leave empty to run for all configured domains.")
+
+	configPath = flag.String("config", "",
+		"Path to the config file. Defaults to $HOME/.porkbungo.\n"+
+			"Files ending in .yaml, .yml, or .toml are read as YAML or TOML, all others as JSON.\n"+
+			"Use \"-\" to read the config from stdin, e.g. from a secret manager.")
 
 	timeout = flag.Duration("timeout", 60*time.Second,
 		"Timeout to use for all Porkbun requests combined.")
+
+	logFormat = flag.String("log-format", "text",
+		"The format of log output: \"text\" for human-readable logs or \"json\" for JSON logs.")
+
+	quiet = flag.Bool("quiet", false,
+		"If true, only logs errors. A successful run without changes produces no output.")
+
+	verbose = flag.Bool("verbose", false,
+		"If true, also logs debug details such as the URLs and timings of Porkbun requests.")
+
+	retries = flag.Int("retries", 1,
+		"Maximum number of attempts for each Porkbun request.\n"+
+			"Only network errors and HTTP 429 and 5xx responses are retried.")
+
+	ipv4Endpoint = flag.Bool("ipv4-endpoint", true,
+		"If true, sends all Porkbun requests to the IPv4-only API endpoint, so that the ping API\n"+
+			"(used by -check-auth and -ip-source porkbun) reports the public IPv4 address.\n"+
+			"If false, uses the dual-stack endpoint, which reports the address of whichever family\n"+
+			"the connection uses. -family ipv6 always uses the dual-stack endpoint to detect the IP.")
+
+	requestTimeout = flag.Duration("request-timeout", 0,
+		"Timeout of each attempt of a Porkbun request, e.g. so that a hung connection is retried\n"+
+			"(see -retries). -timeout still limits the total time. Set to 0 to disable.")
 )
 
-// ipChanged returns true if there is a typ record ("A", "AAAA") in records that matches name and has ip as its content.
-func recordExists(records []*api.Record, typ string, name string, content string) bool {
-	for _, r := range records {
-		if r.Type != typ {
-			continue
-		}
-		if r.Name == name && r.Content == content {
-			return true
+// cache is the -ip-cache-ttl cache, or nil if disabled.
+var cache *ipCache
+
+// healthyStatus holds the parsed -check-status codes.
+var healthyStatus []int
+
+// parseStatusCodes parses a comma-separated list of HTTP status codes.
+func parseStatusCodes(s string) ([]int, error) {
+	var codes []int
+	for _, f := range strings.Split(s, ",") {
+		code, err := strconv.Atoi(strings.TrimSpace(f))
+		if err != nil || code < 100 || code > 599 {
+			return nil, fmt.Errorf("invalid HTTP status code %q", f)
 		}
+		codes = append(codes, code)
 	}
-	return false
+	return codes, nil
 }
 
 func dotjoin(subdom, domain string) string {
@@ -58,82 +281,368 @@ func dotjoin(subdom, domain string) string {
 	return subdom + "." + domain
 }
 
-func doDynDNSUpdate(client *porkbun.Client, records []*api.Record) {
-	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+// doDynDNSUpdate updates the A or AAAA records of the configured subdomains
+// with the current public IP. It returns true if any record was (or, in
+// -dry-run mode, would have been) updated. Errors are logged before they are returned.
+func doDynDNSUpdate(ctx context.Context, client *porkbun.Client, records api.RecordSet) (changed bool, err error) {
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
 	defer cancel()
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
 
 	// Ultra-fast path:
 	// If a check URL was specified and is available, assume that the current
 	// DNS records are fine (b/c otherwise, we'd expect the check URL to be unreachable).
-	if *ddCheckURL != "" {
-		client := &http.Client{
-			// Don't follow redirects
-			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+	if *ddCheckURL != "" && !*force {
+		// Use a copy of the Porkbun client's HTTP client, to share its transport.
+		checkClient := *client.HTTPClient()
+		if !*checkFollowRedirects {
+			checkClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
 				return http.ErrUseLastResponse
-			},
+			}
 		}
-		checkCtx, checkCancel := context.WithTimeout(ctx, 5*time.Second)
+		checkCtx, checkCancel := context.WithTimeout(ctx, *checkTimeout)
 		defer checkCancel()
-		req, err := http.NewRequestWithContext(checkCtx, "GET", *ddCheckURL, nil)
+		req, err := http.NewRequestWithContext(checkCtx, *checkMethod, *ddCheckURL, nil)
 		if err != nil {
-			log.Fatalf("Cannot create GET request for %s: %v", *ddCheckURL, err)
+			slog.Error(fmt.Sprintf("Cannot create %s request for check URL", *checkMethod), "url", *ddCheckURL, "err", err)
+			return false, fmt.Errorf("cannot create %s request for check URL: %w", *checkMethod, err)
 		}
-		r, err := client.Do(req)
+		r, err := checkClient.Do(req)
 		if err == nil {
-			n, _ := io.Copy(io.Discard, r.Body)
+			// Only the status matters, don't bother reading the body.
 			r.Body.Close()
-			log.Printf("URL check for %s successful (%s, %d bytes). Skipping DNS update.", *ddCheckURL, r.Status, n)
-			return
+			if len(healthyStatus) == 0 || slices.Contains(healthyStatus, r.StatusCode) {
+				slog.Info("URL check successful. Skipping DNS update.",
+					"url", *ddCheckURL, "status", r.Status)
+				client.ObserveDynDNSUpdate()
+				return false, nil
+			}
+			slog.Info("URL check failed", "url", *ddCheckURL, "status", r.Status)
+		} else {
+			slog.Info("URL check failed", "url", *ddCheckURL, "err", err)
 		}
-		log.Printf("URL check for %s failed: %v", *ddCheckURL, err)
+	}
+
+	families := []string{*family}
+	if *family == "both" {
+		families = []string{"ipv4", "ipv6"}
+	}
+	var errs []error
+	var changedFamilies []string
+	for _, fam := range families {
+		changed, err := updateFamily(ctx, client, fam, records)
+		if err != nil {
+			// Keep the changes of the other family.
+			errs = append(errs, err)
+		}
+		if changed {
+			changedFamilies = append(changedFamilies, fam)
+		}
+	}
+	if len(families) > 1 {
+		slog.Info("Dual-stack summary", "domain", client.Config.Domain,
+			"changed", strings.Join(changedFamilies, ","), "failed", len(errs))
+	}
+	if cache != nil {
+		if err := cache.save(); err != nil {
+			slog.Error("Cannot write IP cache", "file", cache.path, "err", err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return len(changedFamilies) > 0, err
+	}
+	client.ObserveDynDNSUpdate()
+	return len(changedFamilies) > 0, nil
+}
+
+// updateFamily updates the A (for family "ipv4") or AAAA (for "ipv6") records of the
+// configured subdomains with the current public IP of that family. It returns true
+// if any record was (or, in -dry-run mode, would have been) updated.
+// Errors are logged before they are returned.
+func updateFamily(ctx context.Context, client *porkbun.Client, family string, records api.RecordSet) (bool, error) {
+	recordType := "A"
+	if family == "ipv6" {
+		recordType = "AAAA"
 	}
 
 	// Get own IP.
-	ping, err := client.Ping(ctx)
+	var ip net.IP
+	if *fixedIP != "" {
+		ip = net.ParseIP(*fixedIP)
+		if ip == nil {
+			slog.Error("Invalid -ip", "ip", *fixedIP)
+			return false, fmt.Errorf("invalid -ip %q", *fixedIP)
+		}
+		slog.Info("Using IP from -ip flag", "ip", ip.String())
+	} else {
+		resolver, err := ipResolver(client, family)
+		if err != nil {
+			slog.Error("Invalid -ip-source", "err", err)
+			return false, fmt.Errorf("invalid -ip-source: %w", err)
+		}
+		ip, err = resolver.PublicIP(ctx)
+		if err != nil {
+			slog.Error("Cannot determine public IP", "err", err)
+			return false, fmt.Errorf("cannot determine public IP: %w", err)
+		}
+		slog.Info("Detected public IP", "ip", ip.String())
+	}
+	if (ip.To4() != nil) != (recordType == "A") {
+		slog.Error(fmt.Sprintf("Not a valid %s address", family), "ip", ip.String())
+		return false, fmt.Errorf("not a valid %s address: %s", family, ip)
+	}
+	if daemonState != nil {
+		daemonState.setIP(client.Config.Domain, family, ip.String())
+	}
+
+	if *ddRecordID != "" {
+		changed, err := updateRecordByID(ctx, client, recordType, ip)
+		if err != nil {
+			slog.Error("Failed to update record", "type", recordType, "record_id", *ddRecordID, "err", err)
+		}
+		return changed, err
+	}
+
+	anyChanged := false
+	var errs []error
+	subdomains := strings.Split(*ddSubdomain, ",")
+	for i := range subdomains {
+		subdomains[i] = api.RelativeName(strings.TrimSpace(subdomains[i]), client.Config.Domain)
+	}
+	results := make([]string, len(subdomains))
+	for i, subdomain := range subdomains {
+		domain := dotjoin(subdomain, client.Config.Domain)
+		if cache != nil && !*force && cache.fresh(recordType, domain, ip.String()) {
+			slog.Info("Current IP matches cached IP. No update required.",
+				"ip", ip.String(), "domain", domain)
+			results[i] = "unchanged"
+			continue
+		}
+		changed, err := updateRecord(ctx, client, recordType, ip, subdomain, records)
+		if cache != nil {
+			if err != nil {
+				cache.invalidate(recordType, domain)
+			} else if !*dryRun {
+				cache.set(recordType, domain, ip.String())
+			}
+		}
+		switch {
+		case err != nil:
+			slog.Error("Failed to update record", "type", recordType,
+				"domain", dotjoin(subdomain, client.Config.Domain), "err", err)
+			results[i] = "failed"
+			errs = append(errs, fmt.Errorf("%s: %w", domain, err))
+		case changed:
+			results[i] = "updated"
+			anyChanged = true
+		default:
+			results[i] = "unchanged"
+		}
+	}
+	if len(subdomains) > 1 {
+		for i, subdomain := range subdomains {
+			slog.Info("Summary", "domain", dotjoin(subdomain, client.Config.Domain), "result", results[i])
+		}
+	}
+	if len(errs) > 0 {
+		slog.Error(fmt.Sprintf("Failed to update %d of %d records", len(errs), len(subdomains)))
+		// Wrap the causes, so that exitCode sees auth and network errors.
+		return anyChanged, fmt.Errorf("failed to update %d of %d records: %w",
+			len(errs), len(subdomains), errors.Join(errs...))
+	}
+	return anyChanged, nil
+}
+
+// updateRecordByID updates the -record-id record to ip, unless it is up to date already.
+// Other records of the same name are left alone. The record's TTL, priority, and notes
+// are kept, unless -ttl or -notes are set.
+// It returns true if the record was (or, in -dry-run mode, would have been) updated.
+func updateRecordByID(ctx context.Context, client *porkbun.Client, recordType string, ip net.IP) (bool, error) {
+	currentIP := ip.String()
+	r, err := client.RetrieveByID(ctx, *ddRecordID)
 	if err != nil {
-		log.Fatalf("Ping failed: %v", err)
+		return false, fmt.Errorf("failed to retrieve record: %w", err)
+	}
+	if r.Type != recordType {
+		return false, fmt.Errorf("record %s is a %s record, not %s", r.ID, r.Type, recordType)
+	}
+	if !*force && net.ParseIP(r.Content).Equal(ip) {
+		slog.Info("Record already has the current IP. No update required.",
+			"type", recordType, "domain", r.Name, "ip", currentIP, "record_id", r.ID)
+		return false, nil
+	}
+	if *dryRun {
+		slog.Info("Dry run: would update record", "type", recordType, "domain", r.Name, "ip", currentIP, "record_id", r.ID)
+		return true, nil
+	}
+	req := api.UpdateRequest{
+		Name:    api.RelativeName(r.Name, client.Config.Domain),
+		Type:    r.Type,
+		Content: currentIP,
+		Prio:    r.Prio,
 	}
-	currentIP := ping.YourIP
-	log.Printf("Your IP: %s\n", currentIP)
+	if *ttl == 0 {
+		req.TTL = r.TTL
+	}
+	if *notes == "" {
+		req.Notes = r.Notes
+	}
+	if _, err := client.EditRecord(ctx, r.ID, req); err != nil {
+		return false, err
+	}
+	slog.Info("Updated record", "type", recordType, "domain", r.Name, "ip", currentIP, "record_id", r.ID)
+	notifyChange(ctx, client, r.Name, r.Content, currentIP)
+	return true, nil
+}
+
+// updateRecord updates the recordType record of subdomain to ip, unless it is up to date already.
+// It returns true if the record was (or, in -dry-run mode, would have been) updated.
+func updateRecord(ctx context.Context, client *porkbun.Client, recordType string, ip net.IP, subdomain string, records api.RecordSet) (bool, error) {
+	currentIP := ip.String()
 
 	// Fast path:
 	// If the public DNS record for the domain is identical to our current IP,
 	// there is nothing to do.
-	domain := dotjoin(*ddSubdomain, client.Config.Domain)
-	addrs, err := net.LookupHost(domain)
-	if err != nil {
-		log.Printf("Failed to look up %q: %v", domain, err)
-		log.Fatalf("Please set up an A record before running in -dyndns mode")
+	domain := dotjoin(subdomain, client.Config.Domain)
+	var oldIP string
+	if strings.HasPrefix(subdomain, "*") {
+		// Wildcard names cannot be resolved, so compare against
+		// the records at Porkbun instead.
+		existing, err := client.RetrieveByNameType(ctx, recordType, subdomain)
+		if err != nil {
+			return false, fmt.Errorf("failed to retrieve records: %w", err)
+		}
+		if len(existing.Records) == 0 && !*createMissing {
+			return false, fmt.Errorf("no %s record for %s. Please set up the record "+
+				"before running in -dyndns mode, or use -create-missing", recordType, domain)
+		}
+		if len(existing.Records) > 0 {
+			oldIP = existing.Records[0].Content
+		}
+		records = existing.Records
+	} else if *force {
+		slog.Info("Skipping DNS lookup because of -force", "domain", domain)
+	} else if addrs, err := net.DefaultResolver.LookupHost(ctx, domain); err != nil {
+		if !*createMissing {
+			return false, fmt.Errorf("DNS lookup failed: %w. Please set up an %s record "+
+				"before running in -dyndns mode, or use -create-missing", err, recordType)
+		}
+		slog.Info("DNS lookup failed", "domain", domain, "err", err)
 	} else {
 		for _, addr := range addrs {
-			if addr == currentIP {
-				log.Printf("Current IP %s matches public DNS record for %q. No update required.", currentIP, domain)
-				return
+			a := net.ParseIP(addr)
+			if ip.Equal(a) {
+				slog.Info("Current IP matches public DNS record. No update required.",
+					"ip", currentIP, "domain", domain)
+				return false, nil
+			}
+			if oldIP == "" && a != nil && (a.To4() != nil) == (ip.To4() != nil) {
+				oldIP = addr
 			}
 		}
 	}
 
 	// If we have requested all records already, check if the right one exists.
-	if recordExists(records, "A", domain, currentIP) {
-		log.Printf("An A record for %s with IP %s already exists. No update required.",
-			domain, currentIP)
-		return
+	if !*force && records.Exists(domain, recordType, currentIP) {
+		slog.Info("Record already exists. No update required.",
+			"type", recordType, "domain", domain, "ip", currentIP)
+		return false, nil
 	}
 
-	// Update A record for subdoman with current IP.
-	ip := net.ParseIP(currentIP)
-	if ip == nil || ip.To4() == nil {
-		log.Fatalf("Not a valid IPv4 address: %s", currentIP)
+	// Create the record if it doesn't exist yet.
+	if *createMissing {
+		existing, err := client.RetrieveByNameType(ctx, recordType, subdomain)
+		if err != nil {
+			return false, fmt.Errorf("failed to retrieve records: %w", err)
+		}
+		if len(existing.Records) == 0 {
+			if *dryRun {
+				slog.Info("Dry run: would create record", "type", recordType, "domain", domain, "ip", currentIP)
+				return true, nil
+			}
+			var created *api.CreateResponse
+			if recordType == "AAAA" {
+				created, err = client.CreateAAAA(ctx, subdomain, currentIP)
+			} else {
+				created, err = client.CreateA(ctx, subdomain, currentIP)
+			}
+			if err != nil {
+				return false, fmt.Errorf("failed to create record: %w", err)
+			}
+			slog.Info("Created record", "type", recordType, "domain", domain, "ip", currentIP, "record_id", created.ID)
+			notifyChange(ctx, client, domain, "", currentIP)
+			return true, nil
+		}
+	}
+
+	// Update A/AAAA record for subdoman with current IP.
+	if *dryRun {
+		slog.Info("Dry run: would update record", "type", recordType, "domain", domain, "ip", currentIP)
+		return true, nil
+	}
+	var err error
+	if recordType == "AAAA" {
+		_, err = client.EditAllAAAA(ctx, subdomain, currentIP)
+	} else {
+		_, err = client.EditAllA(ctx, subdomain, currentIP)
+	}
+	if err != nil {
+		return false, err
+	}
+	slog.Info("Updated record", "type", recordType, "domain", domain, "ip", currentIP)
+	notifyChange(ctx, client, domain, oldIP, currentIP)
+	return true, nil
+}
+
+// notifyChange POSTs a notification about the changed IP of domain to the -notify-url, if set.
+// Delivery failures are only logged.
+func notifyChange(ctx context.Context, client *porkbun.Client, domain, oldIP, newIP string) {
+	notify(ctx, client, domain, struct {
+		Domain    string    `json:"domain"`
+		OldIP     string    `json:"old_ip"`
+		NewIP     string    `json:"new_ip"`
+		Timestamp time.Time `json:"timestamp"`
+	}{domain, oldIP, newIP, time.Now()})
+}
+
+// notify POSTs payload as JSON to the -notify-url, if set, using the HTTP client
+// of client, so that notifications honor the configured proxy.
+// Delivery failures are only logged.
+func notify(ctx context.Context, client *porkbun.Client, domain string, payload any) {
+	if *notifyURL == "" {
+		return
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		slog.Error("Cannot marshal notification", "err", err)
+		return
 	}
-	_, err = client.EditAllA(ctx, *ddSubdomain, currentIP)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "POST", *notifyURL, bytes.NewReader(body))
 	if err != nil {
-		log.Fatalf("Failed to update A record: %v", err)
+		slog.Error("Cannot create notification request", "url", *notifyURL, "err", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.HTTPClient().Do(req)
+	if err != nil {
+		slog.Error("Failed to send notification", "url", *notifyURL, "err", err)
+		return
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		slog.Error("Failed to send notification", "url", *notifyURL, "status", resp.Status)
+		return
 	}
-	log.Printf("Updated A record for %s to %s", client.Config.Domain, currentIP)
+	slog.Info("Sent notification", "url", *notifyURL, "domain", domain)
 }
 
-func doPrintRecords(client *porkbun.Client) []*api.Record {
-	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+func doPrintRecords(ctx context.Context, client *porkbun.Client) (api.RecordSet, error) {
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
 	defer cancel()
 
 	includeAll := false
@@ -148,37 +657,882 @@ func doPrintRecords(client *porkbun.Client) []*api.Record {
 	// Retrieve and print all DNS records
 	recordsResp, err := client.RetrieveAll(ctx)
 	if err != nil {
-		log.Fatalf("RetrieveAll failed: %v", err)
+		return nil, failure("RetrieveAll failed", "err", err)
 	}
 	records := recordsResp.Records
+	candidates := records
+	if *grep != "" {
+		candidates = candidates.Grep(*grep)
+	}
+	var selected api.RecordSet
+	for _, r := range candidates {
+		if *nameFilter != "" && !matchesName(r.Name, *nameFilter, client.Config.Domain) {
+			continue
+		}
+		if includeAll || include[r.Type] {
+			selected = append(selected, r)
+		}
+	}
+	if *output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if selected == nil {
+			selected = api.RecordSet{}
+		}
+		if err := enc.Encode(selected); err != nil {
+			return nil, failure("Cannot write records", "err", err)
+		}
+		return records, nil
+	}
 	var recordLines []string
+	for _, r := range selected {
+		recordLines = append(recordLines, r.String())
+	}
+	slog.Info(fmt.Sprintf("Your records:\n%s", strings.Join(recordLines, "\n")),
+		"count", len(recordLines))
+	return records, nil
+}
+
+// doSummary prints the number of records of each type, sorted by type.
+func doSummary(ctx context.Context, client *porkbun.Client) error {
+	records, err := retrieveAll(ctx, client)
+	if err != nil {
+		return failure("RetrieveAll failed", "err", err)
+	}
+	counts := records.CountByType()
+	if *output == "json" {
+		if err := json.NewEncoder(os.Stdout).Encode(counts); err != nil {
+			return failure("Cannot write summary", "err", err)
+		}
+		return nil
+	}
+	types := make([]string, 0, len(counts))
+	for typ := range counts {
+		types = append(types, typ)
+	}
+	slices.Sort(types)
+	var parts []string
+	for _, typ := range types {
+		parts = append(parts, fmt.Sprintf("%s: %d", typ, counts[typ]))
+	}
+	fmt.Printf("%s: %s\n", client.Config.Domain, strings.Join(parts, ", "))
+	return nil
+}
+
+// doCreate creates the record given by -type, -name, -content, and -prio,
+// and prints its ID.
+func doCreate(ctx context.Context, client *porkbun.Client) error {
+	typ := strings.ToUpper(*recordType)
+	if !api.ValidRecordType(typ) {
+		return configError(fmt.Sprintf("Invalid -type %q: must be one of %s", *recordType, strings.Join(api.RecordTypes, ", ")))
+	}
+	if *content == "" {
+		return configError("-content is required")
+	}
+	name := api.RelativeName(*nameFilter, client.Config.Domain)
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+	resp, err := client.CreateRecord(ctx, api.UpdateRequest{
+		Name:    name,
+		Type:    typ,
+		Content: *content,
+		Prio:    *prio,
+	})
+	if err != nil {
+		return failure("Failed to create record", "type", typ,
+			"domain", dotjoin(name, client.Config.Domain), "err", err)
+	}
+	slog.Info("Created record", "type", typ, "domain", dotjoin(name, client.Config.Domain),
+		"content", *content, "record_id", resp.ID)
+	fmt.Println(resp.ID)
+	return nil
+}
+
+// doDelete deletes the record with the given -id, or all records of -type and -name,
+// after asking for confirmation (see confirm), and prints the number of deleted records.
+func doDelete(ctx context.Context, client *porkbun.Client) error {
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+	if *recordID != "" {
+		r, err := client.RetrieveByID(ctx, *recordID)
+		if err != nil {
+			return failure("Failed to retrieve record", "record_id", *recordID, "err", err)
+		}
+		if err := confirm(api.RecordSet{r}); err != nil {
+			return err
+		}
+		if _, err := client.DeleteRecord(ctx, *recordID); err != nil {
+			return failure("Failed to delete record", "record_id", *recordID, "err", err)
+		}
+		slog.Info("Deleted record", "record_id", *recordID)
+		fmt.Println(1)
+		return nil
+	}
+	typ := strings.ToUpper(*recordType)
+	if !api.ValidRecordType(typ) {
+		return configError("Either -id or a valid -type is required", "type", *recordType)
+	}
+	name := api.RelativeName(*nameFilter, client.Config.Domain)
+	domain := dotjoin(name, client.Config.Domain)
+	resp, err := client.RetrieveByNameType(ctx, typ, name)
+	if err != nil {
+		return failure("Failed to retrieve records", "type", typ, "domain", domain, "err", err)
+	}
+	if len(resp.Records) == 0 {
+		slog.Info("No records to delete", "type", typ, "domain", domain)
+		fmt.Println(0)
+		return nil
+	}
+	if err := confirm(resp.Records); err != nil {
+		return err
+	}
+	if _, err := client.DeleteByNameType(ctx, typ, name); err != nil {
+		return failure("Failed to delete records", "type", typ, "domain", domain, "err", err)
+	}
+	slog.Info("Deleted records", "type", typ, "domain", domain, "count", len(resp.Records))
+	fmt.Println(len(resp.Records))
+	return nil
+}
+
+// confirm returns nil if the records may be deleted: if -yes is set, or stdin is
+// a terminal and the user confirms the prompt. Otherwise, it returns a config error.
+func confirm(records api.RecordSet) error {
+	if *yes {
+		return nil
+	}
+	if !isTerminal(os.Stdin) {
+		return configError("Refusing to delete records without -yes", "count", len(records))
+	}
 	for _, r := range records {
-		if includeAll || include[r.Type] {
-			recordLines = append(recordLines, r.String())
+		fmt.Fprintln(os.Stderr, r)
+	}
+	fmt.Fprintf(os.Stderr, "Delete %d record(s)? [y/N] ", len(records))
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+		return failure("Deletion aborted")
+	}
+	return nil
+}
+
+// doCheckAuth verifies the client's API keys using the ping API.
+func doCheckAuth(ctx context.Context, client *porkbun.Client) error {
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+	resp, err := client.Ping(ctx)
+	if err != nil {
+		var apiErr *porkbun.APIError
+		if errors.As(err, &apiErr) {
+			return fmt.Errorf("API keys for %s are invalid: %w", client.Config.Domain, err)
+		}
+		return fmt.Errorf("cannot verify API keys for %s: %w", client.Config.Domain, err)
+	}
+	slog.Info("API keys are valid", "domain", client.Config.Domain, "ip", resp.YourIP)
+	return nil
+}
+
+// doMyIP prints the public IPv4 and IPv6 addresses of the host.
+func doMyIP(ctx context.Context, client *porkbun.Client) error {
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+	ips, err := client.PingBoth(ctx)
+	if err != nil {
+		return failure("Cannot determine public IP", "err", err)
+	}
+	if *output == "json" {
+		if err := json.NewEncoder(os.Stdout).Encode(map[string]string{"ipv4": ips.IPv4, "ipv6": ips.IPv6}); err != nil {
+			return failure("Cannot write IPs", "err", err)
+		}
+		return nil
+	}
+	for _, ip := range []struct {
+		family, ip string
+		err        error
+	}{{"IPv4", ips.IPv4, ips.IPv4Err}, {"IPv6", ips.IPv6, ips.IPv6Err}} {
+		if ip.err != nil {
+			slog.Info(fmt.Sprintf("No public %s address", ip.family), "err", ip.err)
+			fmt.Printf("%s: none\n", ip.family)
+			continue
+		}
+		fmt.Printf("%s: %s\n", ip.family, ip.ip)
+	}
+	return nil
+}
+
+// doStatus prints the status of the domain as listed in the account.
+func doStatus(ctx context.Context, client *porkbun.Client) error {
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+	info, err := client.GetDomainInfo(ctx)
+	if err != nil {
+		return failure("Cannot get domain status", "domain", client.Config.Domain, "err", err)
+	}
+	if *output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(info); err != nil {
+			return failure("Cannot write status", "err", err)
+		}
+		return nil
+	}
+	fmt.Println(formatDomainStatus(info, time.Now()))
+	return nil
+}
+
+// formatDomainStatus returns a one-line summary of info, e.g.
+// "example.com: ACTIVE, expires 2027-01-01 23:59:59 (in 79 days), auto-renew on".
+func formatDomainStatus(info *api.DomainInfo, now time.Time) string {
+	expires := info.ExpireDate
+	if t, err := time.Parse(time.DateTime, info.ExpireDate); err == nil {
+		expires += fmt.Sprintf(" (in %d days)", int(t.Sub(now).Hours()/24))
+	}
+	autoRenew := "off"
+	if info.AutoRenew {
+		autoRenew = "on"
+	}
+	return fmt.Sprintf("%s: %s, expires %s, auto-renew %s", info.Domain, info.Status, expires, autoRenew)
+}
+
+// matchesName returns true if recordName, a fully qualified record name,
+// is name or one of its subdomains. name may be relative to domain.
+func matchesName(recordName, name, domain string) bool {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	if name != domain && !strings.HasSuffix(name, "."+domain) {
+		name = dotjoin(name, domain)
+	}
+	recordName = strings.ToLower(strings.TrimSuffix(recordName, "."))
+	return recordName == name || strings.HasSuffix(recordName, "."+name)
+}
+
+// doSync reconciles the records of the domain with the -sync file.
+func doSync(ctx context.Context, client *porkbun.Client) error {
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+
+	data, err := os.ReadFile(*syncFile)
+	if err != nil {
+		return failure("Cannot read -sync file", "err", err)
+	}
+	desired, err := porkbun.DecodeDesired(data, filepath.Ext(*syncFile))
+	if err != nil {
+		return failure("Invalid -sync file", "file", *syncFile, "err", err)
+	}
+	plan, err := client.PlanSync(ctx, desired, *prune)
+	if err != nil {
+		return failure("Cannot plan sync", "domain", client.Config.Domain, "err", err)
+	}
+	if len(plan) == 0 {
+		slog.Info("Records are in sync. No changes required.", "domain", client.Config.Domain)
+		return nil
+	}
+	fmt.Printf("Plan for %s:\n", client.Config.Domain)
+	color := useColor(os.Stdout)
+	for _, ch := range plan {
+		if color {
+			fmt.Printf("%s%s%s\n", changeColors[ch.Kind], ch, ansiReset)
+		} else {
+			fmt.Println(ch)
+		}
+	}
+	if *dryRun {
+		slog.Info("Dry run: not applying sync plan", "domain", client.Config.Domain, "changes", len(plan))
+		return nil
+	}
+	if err := client.ApplyPlan(ctx, plan); err != nil {
+		return failure("Sync failed", "domain", client.Config.Domain, "err", err)
+	}
+	slog.Info("Applied sync plan", "domain", client.Config.Domain, "changes", len(plan))
+	return nil
+}
+
+const ansiReset = "\x1b[0m"
+
+// changeColors are the ANSI colors of sync plan changes.
+var changeColors = map[porkbun.ChangeKind]string{
+	porkbun.ChangeCreate: "\x1b[32m",
+	porkbun.ChangeUpdate: "\x1b[33m",
+	porkbun.ChangeDelete: "\x1b[31m",
+}
+
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// useColor returns true if output to f should be colorized:
+// f is a terminal and the NO_COLOR environment variable is not set.
+func useColor(f *os.File) bool {
+	return isTerminal(f) && os.Getenv("NO_COLOR") == ""
+}
+
+// doExport writes the current records of the domain to the -export zone file.
+func doExport(ctx context.Context, client *porkbun.Client) error {
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+	resp, err := client.RetrieveAll(ctx)
+	if err != nil {
+		return failure("RetrieveAll failed", "err", err)
+	}
+	w := os.Stdout
+	if *exportFile != "-" {
+		f, err := os.Create(*exportFile)
+		if err != nil {
+			return failure("Cannot create -export file", "err", err)
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := zone.WriteZoneFile(w, client.Config.Domain, resp.Records); err != nil {
+		return failure("Cannot write zone file", "file", *exportFile, "err", err)
+	}
+	if err := w.Sync(); err != nil && *exportFile != "-" {
+		return failure("Cannot write zone file", "file", *exportFile, "err", err)
+	}
+	slog.Info("Exported records", "domain", client.Config.Domain, "file", *exportFile, "count", len(resp.Records))
+	return nil
+}
+
+// doImport creates the records of the -import zone file.
+func doImport(ctx context.Context, client *porkbun.Client) error {
+	f, err := os.Open(*importFile)
+	if err != nil {
+		return failure("Cannot open -import file", "err", err)
+	}
+	defer f.Close()
+	reqs, err := zone.ParseZoneFile(f, client.Config.Domain)
+	if err != nil {
+		return failure("Invalid zone file", "file", *importFile, "err", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+	failed := 0
+	for _, req := range reqs {
+		name := dotjoin(req.Name, client.Config.Domain)
+		if *dryRun {
+			slog.Info("Dry run: would create record", "type", req.Type, "domain", name, "content", req.Content)
+			continue
+		}
+		resp, err := client.CreateRecord(ctx, req)
+		if err != nil {
+			slog.Error("Failed to create record", "type", req.Type, "domain", name, "content", req.Content, "err", err)
+			failed++
+			continue
+		}
+		slog.Info("Created record", "type", req.Type, "domain", name, "content", req.Content, "record_id", resp.ID)
+	}
+	if failed > 0 {
+		return failure(fmt.Sprintf("Failed to import %d of %d records", failed, len(reqs)))
+	}
+	return nil
+}
+
+// doImportCSV creates the records of the -import-csv file, see readCSVRecords.
+// Each failed row is logged with its line number.
+func doImportCSV(ctx context.Context, client *porkbun.Client) error {
+	f, err := os.Open(*importCSV)
+	if err != nil {
+		return failure("Cannot open -import-csv file", "err", err)
+	}
+	defer f.Close()
+	records, err := readCSVRecords(f)
+	if err != nil {
+		return failure("Invalid CSV file", "file", *importCSV, "err", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+	var failedLines []string
+	for _, rec := range records {
+		req := rec.req
+		name := dotjoin(api.RelativeName(req.Name, client.Config.Domain), client.Config.Domain)
+		if rec.err != nil {
+			slog.Error("Invalid row", "line", rec.line, "err", rec.err)
+			failedLines = append(failedLines, strconv.Itoa(rec.line))
+			continue
+		}
+		if *dryRun {
+			slog.Info("Dry run: would create record", "line", rec.line, "type", req.Type, "domain", name, "content", req.Content)
+			continue
+		}
+		resp, err := client.CreateRecord(ctx, req)
+		if err != nil {
+			slog.Error("Failed to create record", "line", rec.line, "type", req.Type, "domain", name,
+				"content", req.Content, "err", err)
+			failedLines = append(failedLines, strconv.Itoa(rec.line))
+			continue
+		}
+		slog.Info("Created record", "line", rec.line, "type", req.Type, "domain", name,
+			"content", req.Content, "record_id", resp.ID)
+	}
+	if len(failedLines) > 0 {
+		return failure(fmt.Sprintf("Failed to import %d of %d records", len(failedLines), len(records)),
+			"lines", strings.Join(failedLines, ","))
+	}
+	return nil
+}
+
+// doACME runs the certbot -acme-auth or -acme-cleanup hook using
+// the client whose domain contains $CERTBOT_DOMAIN.
+func doACME(ctx context.Context, clients []*porkbun.Client) error {
+	domain := strings.TrimSuffix(os.Getenv("CERTBOT_DOMAIN"), ".")
+	if domain == "" {
+		return failure("CERTBOT_DOMAIN is not set")
+	}
+	var client *porkbun.Client
+	for _, c := range clients {
+		if domain == c.Config.Domain || strings.HasSuffix(domain, "."+c.Config.Domain) {
+			client = c
+			break
+		}
+	}
+	if client == nil {
+		return failure("No configured domain matches CERTBOT_DOMAIN", "domain", domain)
+	}
+	provider := porkbunacme.NewProvider(client)
+
+	reqCtx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+	if *acmeCleanup {
+		if err := provider.DeleteRecords(reqCtx, domain); err != nil {
+			return failure("Cannot clean up ACME challenge", "domain", domain, "err", err)
+		}
+		slog.Info("Deleted ACME challenge records", "domain", domain)
+		return nil
+	}
+	validation := os.Getenv("CERTBOT_VALIDATION")
+	if validation == "" {
+		return failure("CERTBOT_VALIDATION is not set")
+	}
+	if err := provider.CreateRecord(reqCtx, domain, validation); err != nil {
+		return failure("Cannot create ACME challenge", "domain", domain, "err", err)
+	}
+	slog.Info("Created ACME challenge record", "domain", domain)
+	if *acmeWait > 0 {
+		waitCtx, cancel := context.WithTimeout(ctx, *acmeWait)
+		defer cancel()
+		if err := provider.WaitForRecord(waitCtx, domain, validation); err != nil {
+			return failure("ACME challenge record did not propagate", "domain", domain, "err", err)
+		}
+		slog.Info("ACME challenge record propagated", "domain", domain)
+	}
+	return nil
+}
+
+// ipResolver returns a resolver for the public IP address of the given family
+// that tries the -ip-source providers in order.
+func ipResolver(client *porkbun.Client, family string) (publicip.IPResolver, error) {
+	v6 := family == "ipv6"
+	dialFamily, tcp, udp, ipifyURL := "ip4", "tcp4", "udp4", publicip.IpifyV4URL
+	if v6 {
+		dialFamily, tcp, udp, ipifyURL = "ip6", "tcp6", "udp6", publicip.IpifyV6URL
+	}
+	// Send the HTTP lookups through the configured proxy, like API requests.
+	transport, _ := client.HTTPClient().Transport.(*http.Transport)
+	var resolvers publicip.Fallback
+	for _, src := range strings.Split(*ipSource, ",") {
+		switch strings.TrimSpace(src) {
+		case "porkbun":
+			// As in PingBoth, ping over the family's network. The IPv4-only
+			// endpoint can only ever report our IPv4 address.
+			opts := append(clientOptions(), porkbun.WithDialFamily(dialFamily))
+			if !v6 && *ipv4Endpoint {
+				opts = append(opts, porkbun.WithIPv4())
+			}
+			pc := porkbun.NewClient(client.Config, opts...)
+			resolvers = append(resolvers, &publicip.PorkbunResolver{Client: pc})
+		case "ipify":
+			resolvers = append(resolvers, &publicip.HTTPResolver{URL: ipifyURL, Network: tcp, Transport: transport})
+		case "ifconfig.me":
+			resolvers = append(resolvers, &publicip.HTTPResolver{URL: publicip.IfconfigMeURL, Network: tcp, Transport: transport})
+		case "stun":
+			resolvers = append(resolvers, &publicip.STUNResolver{Server: publicip.GoogleSTUNServer, Network: udp})
+		default:
+			return nil, fmt.Errorf("unknown IP source %q", src)
+		}
+	}
+	return resolvers, nil
+}
+
+// runDaemon runs the dyndns update for all clients every -interval until ctx is done.
+func runDaemon(ctx context.Context, clients []*porkbun.Client) error {
+	slog.Info("Running in daemon mode", "interval", *interval)
+	for _, client := range clients {
+		if err := doCheckAuth(ctx, client); err != nil {
+			return failure("Credentials check failed", "err", err)
+		}
+	}
+	daemonState = newDaemonStatus()
+	if *listen != "" {
+		if err := serveHealth(ctx, *listen, daemonState, time.Duration(*readyIntervals)*(*interval)); err != nil {
+			return configError("Cannot listen on -listen address", "err", err)
+		}
+	}
+	// Log the status on request, e.g. to debug a daemon that seems stuck.
+	dump := make(chan os.Signal, 1)
+	if len(statusSignals) > 0 {
+		signal.Notify(dump, statusSignals...)
+		defer signal.Stop(dump)
+	}
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		var errs []error
+		anyChanged := false
+		for _, client := range clients {
+			// Errors are logged already; retry in the next interval.
+			changed, err := doDynDNSUpdate(ctx, client, nil)
+			if err != nil {
+				errs = append(errs, err)
+			}
+			anyChanged = anyChanged || changed
+		}
+		daemonState.record(anyChanged, errors.Join(errs...))
+		for waiting := true; waiting; {
+			select {
+			case <-ctx.Done():
+				slog.Info("Shutting down")
+				return nil
+			case <-dump:
+				daemonState.log()
+			case <-ticker.C:
+				waiting = false
+			}
+		}
+	}
+}
+
+// runWatch retrieves the records of all clients every -interval until ctx is done,
+// and reports the changes compared to the previous retrieval.
+func runWatch(ctx context.Context, clients []*porkbun.Client) error {
+	slog.Info("Watching records", "interval", *interval)
+	snapshots := make([]api.RecordSet, len(clients))
+	for i, client := range clients {
+		records, err := retrieveAll(ctx, client)
+		if err != nil {
+			return failure("RetrieveAll failed", "domain", client.Config.Domain, "err", err)
+		}
+		snapshots[i] = records
+		slog.Info("Retrieved records", "domain", client.Config.Domain, "count", len(records))
+	}
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Shutting down")
+			return nil
+		case <-ticker.C:
+		}
+		for i, client := range clients {
+			records, err := retrieveAll(ctx, client)
+			if err != nil {
+				// Retry in the next interval.
+				slog.Error("RetrieveAll failed", "domain", client.Config.Domain, "err", err)
+				continue
+			}
+			reportChanges(ctx, client, snapshots[i].Diff(records))
+			snapshots[i] = records
 		}
 	}
-	log.Printf("Your records:\n%s", strings.Join(recordLines, "\n"))
-	return records
+}
+
+func retrieveAll(ctx context.Context, client *porkbun.Client) (api.RecordSet, error) {
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+	resp, err := client.RetrieveAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Records, nil
+}
+
+// reportChanges logs each change in d and sends it to the -notify-url.
+func reportChanges(ctx context.Context, client *porkbun.Client, d *api.RecordSetDiff) {
+	domain := client.Config.Domain
+	type change struct {
+		Domain    string      `json:"domain"`
+		Change    string      `json:"change"`
+		Old       *api.Record `json:"old,omitempty"`
+		New       *api.Record `json:"new,omitempty"`
+		Timestamp time.Time   `json:"timestamp"`
+	}
+	for _, r := range d.Added {
+		slog.Warn("Record added", "domain", domain, "record", r)
+		notify(ctx, client, domain, change{domain, "added", nil, r, time.Now()})
+	}
+	for _, r := range d.Removed {
+		slog.Warn("Record removed", "domain", domain, "record", r)
+		notify(ctx, client, domain, change{domain, "removed", r, nil, time.Now()})
+	}
+	for _, c := range d.Modified {
+		slog.Warn("Record modified", "domain", domain, "old", c.Old, "new", c.New)
+		notify(ctx, client, domain, change{domain, "modified", c.Old, c.New, time.Now()})
+	}
+}
+
+// clientOptions returns the porkbun.Client options configured via flags.
+func clientOptions() []porkbun.Option {
+	opts := []porkbun.Option{
+		porkbun.WithRetry(*retries, time.Second),
+		porkbun.WithLogger(porkbun.NewSlogLogger(slog.Default())),
+	}
+	if *requestTimeout > 0 {
+		opts = append(opts, porkbun.WithRequestTimeout(*requestTimeout))
+	}
+	if *ttl != 0 {
+		opts = append(opts, porkbun.WithTTL(*ttl))
+	}
+	if *notes != "" {
+		opts = append(opts, porkbun.WithNotes(*notes))
+	}
+	return opts
+}
+
+// configFilePath returns the -config path, or the default config file in $HOME.
+func configFilePath() (string, error) {
+	if *configPath != "" {
+		return *configPath, nil
+	}
+	home := os.Getenv("HOME")
+	if home == "" {
+		return "", fmt.Errorf("$HOME is not set, use -config to specify the config file")
+	}
+	return filepath.Join(home, ".porkbungo"), nil
 }
 
 func main() {
-	flag.Parse()
+	err := run(os.Args[1:])
+	if err == nil {
+		return
+	}
+	var ce *cliError
+	if !errors.As(err, &ce) {
+		ce = failure("Error", "err", err).(*cliError)
+	}
+	if !ce.logged {
+		slog.Error(ce.msg, ce.args...)
+	}
+	os.Exit(ce.code)
+}
 
-	configFile := path.Join(os.Getenv("HOME"), ".porkbungo")
-	config, err := porkbun.ReadClientConfig(configFile)
+// run runs the CLI and returns a *cliError with the exit code on failure.
+func run(args []string) error {
+	deprecated, err := parseArgs(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
 	if err != nil {
-		log.Fatalf("Cannot read config: %v", err)
+		return configError("Invalid command line", "err", err)
 	}
-	log.Printf("Read config from %s. Running for domain %q.", configFile, config.Domain)
 
-	client := porkbun.NewClient(config, true)
+	if *output != "text" && *output != "json" {
+		return configError(fmt.Sprintf("Invalid -output %q: must be \"text\" or \"json\"", *output))
+	}
+	if *quiet && *verbose {
+		return configError("-quiet and -verbose are mutually exclusive")
+	}
+	if *retries < 1 {
+		return configError("-retries must be at least 1")
+	}
+	logLevel := slog.LevelInfo
+	switch {
+	case *quiet:
+		logLevel = slog.LevelError
+	case *verbose:
+		logLevel = slog.LevelDebug
+	case *output == "json" && (*printRecords != "" || *status):
+		// Keep the output machine-consumable.
+		logLevel = slog.LevelWarn
+	}
+	switch *logFormat {
+	case "text":
+		// Keep the default logger, which writes through the standard log package.
+		slog.SetLogLoggerLevel(logLevel)
+	case "json":
+		slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel})))
+	default:
+		return configError(fmt.Sprintf("Invalid -log-format %q: must be \"text\" or \"json\"", *logFormat))
+	}
+	if deprecated {
+		slog.Warn("Using flags like -dyndns or -print without a command is deprecated " +
+			"and will be removed in a future release. Run with -help to list commands.")
+	}
+
+	if *family != "ipv4" && *family != "ipv6" && *family != "both" {
+		return configError(fmt.Sprintf("Invalid -family %q: must be \"ipv4\", \"ipv6\", or \"both\"", *family))
+	}
+	if *ddRecordID != "" && (*ddSubdomain != "" || *createMissing || *family == "both") {
+		return configError("-record-id cannot be combined with -subdomain, -create-missing, or -family both")
+	}
+	if *family == "both" && *fixedIP != "" {
+		return configError("-ip cannot be used with -family both")
+	}
 
-	var records []*api.Record
-	if *printRecords != "" {
-		records = doPrintRecords(client)
+	if *checkMethod != "GET" && *checkMethod != "HEAD" {
+		return configError(fmt.Sprintf("Invalid -check-method %q: must be \"GET\" or \"HEAD\"", *checkMethod))
+	}
+	if *checkStatus != "" {
+		codes, err := parseStatusCodes(*checkStatus)
+		if err != nil {
+			return configError("Invalid -check-status", "err", err)
+		}
+		healthyStatus = codes
 	}
 
-	if *dyndns {
-		doDynDNSUpdate(client, records)
+	if *ttl != 0 && *ttl < porkbun.MinTTL {
+		return configError(fmt.Sprintf("Invalid -ttl %d: must be at least %d", *ttl, porkbun.MinTTL))
+	}
+
+	configFile, err := configFilePath()
+	if err != nil {
+		return configError("Cannot determine config file", "err", err)
+	}
+	config, err := porkbun.LoadClientConfig(configFile)
+	if err != nil {
+		return configError("Cannot read config", "err", err)
+	}
+	configs := config.DomainConfigs()
+	if *domainFlag != "" {
+		dc, err := config.ForDomain(*domainFlag)
+		if err != nil {
+			return configError("Invalid -domain", "err", err)
+		}
+		configs = []*porkbun.ClientConfig{dc}
+	}
+
+	if *ipCacheTTL > 0 {
+		if configFile == porkbun.StdinConfig {
+			return configError("-ip-cache-ttl requires a -config file")
+		}
+		cache, err = loadIPCache(configFile+".ipcache.json", *ipCacheTTL)
+		if err != nil {
+			return failure("Cannot read IP cache", "err", err)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var clients []*porkbun.Client
+	for _, dc := range configs {
+		slog.Info("Read config", "file", configFile, "domain", dc.Domain)
+		opts := clientOptions()
+		if *ipv4Endpoint {
+			opts = append(opts, porkbun.WithIPv4())
+		}
+		clients = append(clients, porkbun.NewClient(dc, opts...))
+	}
+
+	if (*create || *deleteRecords || *ddRecordID != "") && len(clients) > 1 {
+		return configError("Multiple domains are configured, use -domain to select one")
+	}
+
+	if *myIP {
+		// The IPs do not depend on the domain, so any client will do.
+		return doMyIP(ctx, clients[0])
+	}
+
+	if *acmeAuth || *acmeCleanup {
+		if *acmeAuth && *acmeCleanup {
+			return configError("-acme-auth and -acme-cleanup are mutually exclusive")
+		}
+		return doACME(ctx, clients)
+	}
+
+	if *listen != "" && !*daemon {
+		return configError("-listen requires -daemon")
+	}
+	if *readyIntervals < 1 {
+		return configError("-ready-intervals must be at least 1")
+	}
+	if *watch && *daemon {
+		return configError("-watch and -daemon are mutually exclusive")
+	}
+
+	updated := false
+	for _, client := range clients {
+		var records api.RecordSet
+		if *printRecords != "" {
+			if records, err = doPrintRecords(ctx, client); err != nil {
+				return err
+			}
+		}
+
+		if *summary {
+			if err := doSummary(ctx, client); err != nil {
+				return err
+			}
+		}
+
+		if *create {
+			if err := doCreate(ctx, client); err != nil {
+				return err
+			}
+		}
+
+		if *deleteRecords {
+			if err := doDelete(ctx, client); err != nil {
+				return err
+			}
+		}
+
+		if *checkAuth {
+			if err := doCheckAuth(ctx, client); err != nil {
+				return failure("Credentials check failed", "err", err)
+			}
+		}
+
+		if *status {
+			if err := doStatus(ctx, client); err != nil {
+				return err
+			}
+		}
+
+		if *exportFile != "" {
+			if err := doExport(ctx, client); err != nil {
+				return err
+			}
+		}
+
+		if *importFile != "" {
+			if err := doImport(ctx, client); err != nil {
+				return err
+			}
+		}
+
+		if *importCSV != "" {
+			if err := doImportCSV(ctx, client); err != nil {
+				return err
+			}
+		}
+
+		if *syncFile != "" {
+			if err := doSync(ctx, client); err != nil {
+				return err
+			}
+		}
+
+		if *dyndns && !*daemon {
+			changed, err := doDynDNSUpdate(ctx, client, records)
+			if err != nil {
+				// doDynDNSUpdate logged the error already.
+				return loggedError(err)
+			}
+			if changed {
+				updated = true
+			}
+		}
+	}
+	if *dryRun && updated {
+		return errUpdateNeeded
+	}
+
+	if *daemon {
+		return runDaemon(ctx, clients)
+	}
+	if *watch {
+		return runWatch(ctx, clients)
 	}
+	return nil
 }
@@ -0,0 +1,21 @@
+package main
+
+import "testing"
+
+func TestMatchesName(t *testing.T) {
+	tests := []struct {
+		recordName, name, domain string
+		want                     bool
+	}{
+		{"www.example.com", "www", "example.com", true},
+		{"www.example.com", "www", "Example.COM", true},
+		{"a.www.example.com", "WWW.example.com.", "EXAMPLE.com", true},
+		{"WWW.Example.com", "www", "example.com.", true},
+		{"mail.example.com", "www", "Example.com", false},
+	}
+	for _, tc := range tests {
+		if got := matchesName(tc.recordName, tc.name, tc.domain); got != tc.want {
+			t.Errorf("matchesName(%q, %q, %q) = %v, want %v", tc.recordName, tc.name, tc.domain, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// statusSignals make -daemon mode log its status. There is no SIGUSR1 on this platform.
+var statusSignals []os.Signal
@@ -0,0 +1,11 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// statusSignals make -daemon mode log its status.
+var statusSignals = []os.Signal{syscall.SIGUSR1}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// daemonStatus tracks the state of the -daemon update runs, for the -listen
+// endpoints and the SIGUSR1 dump.
+type daemonStatus struct {
+	mu          sync.Mutex
+	lastRun     time.Time
+	lastSuccess time.Time // Zero until the first successful run.
+	lastErr     error
+	runs        int
+	updates     int               // Number of runs that changed records.
+	ips         map[string]string // Last detected IP by "domain family".
+}
+
+// daemonState is the status of the running daemon, or nil if not in -daemon mode.
+var daemonState *daemonStatus
+
+func newDaemonStatus() *daemonStatus {
+	return &daemonStatus{ips: make(map[string]string)}
+}
+
+// record records the outcome of an update run.
+func (s *daemonStatus) record(changed bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastRun = time.Now()
+	s.lastErr = err
+	s.runs++
+	if changed {
+		s.updates++
+	}
+	if err == nil {
+		s.lastSuccess = s.lastRun
+	}
+}
+
+// setIP records the IP detected for family ("ipv4" or "ipv6") of domain.
+func (s *daemonStatus) setIP(domain, family, ip string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ips[domain+" "+family] = ip
+}
+
+// log logs the current status.
+func (s *daemonStatus) log() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]string, 0, len(s.ips))
+	for k := range s.ips {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	var ips []string
+	for _, k := range keys {
+		ips = append(ips, k+"="+s.ips[k])
+	}
+	args := []any{"ips", strings.Join(ips, ", "), "runs", s.runs, "updates", s.updates,
+		"last_run", s.lastRun.Format(time.RFC3339), "last_success", s.lastSuccess.Format(time.RFC3339)}
+	if s.lastErr != nil {
+		args = append(args, "last_err", s.lastErr)
+	}
+	slog.Info("Daemon status", args...)
+}
+
+// ready returns nil if the last successful run was less than maxAge ago.
+func (s *daemonStatus) ready(maxAge time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.lastSuccess.IsZero() {
+		if s.lastErr != nil {
+			return fmt.Errorf("no successful update yet, last error: %v", s.lastErr)
+		}
+		return fmt.Errorf("no successful update yet")
+	}
+	if age := time.Since(s.lastSuccess); age > maxAge {
+		if s.lastErr != nil {
+			return fmt.Errorf("last successful update was %v ago, last error: %v", age.Round(time.Second), s.lastErr)
+		}
+		return fmt.Errorf("last successful update was %v ago", age.Round(time.Second))
+	}
+	return nil
+}
+
+// serveHealth serves /healthz and /readyz on addr until ctx is done.
+// /healthz always succeeds while the process is running. /readyz succeeds
+// if the last successful update run was at most maxAge ago.
+func serveHealth(ctx context.Context, addr string, s *daemonStatus, maxAge time.Duration) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if err := s.ready(maxAge); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := srv.Serve(l); err != nil && err != http.ErrServerClosed {
+			slog.Error("Health endpoint failed", "addr", addr, "err", err)
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+	slog.Info("Serving health endpoints", "addr", l.Addr().String())
+	return nil
+}
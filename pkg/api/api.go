@@ -1,17 +1,54 @@
 package api
 
-import "fmt"
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// RecordTypes lists the DNS record types supported by the Porkbun API.
+var RecordTypes = []string{"A", "MX", "CNAME", "ALIAS", "TXT", "NS", "AAAA", "SRV", "TLSA", "CAA", "HTTPS", "SVCB"}
+
+// Secret is an API key. Its String and GoString methods redact the value,
+// so that keys do not leak into logs or error messages.
+type Secret string
+
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return "***"
+}
+
+// GoString redacts s for the %#v verb, see String.
+func (s Secret) GoString() string {
+	return `"` + s.String() + `"`
+}
 
 type Keys struct {
-	SecretAPIKey string `json:"secretapikey"`
-	APIKey       string `json:"apikey"`
+	SecretAPIKey Secret `json:"secretapikey"`
+	APIKey       Secret `json:"apikey"`
 }
 
+// Values of Status.Status.
+const (
+	StatusSuccess = "SUCCESS"
+	StatusError   = "ERROR"
+)
+
 type Status struct {
 	Status  string `json:"status"`
 	Message string `json:"message"`
 }
 
+func (s Status) GetStatus() Status {
+	return s
+}
+
 type PingRequest struct {
 	Keys
 }
@@ -37,7 +74,111 @@ type RecordsRequest struct {
 
 type RecordsResponse struct {
 	Status
-	Records []*Record `json:"records"`
+	Records RecordSet `json:"records"`
+}
+
+// RecordSet is a list of records, e.g. as returned by a retrieve call.
+type RecordSet []*Record
+
+// Find returns all records with the given name and type.
+// name is the fully qualified name, as in Record.Name.
+func (rs RecordSet) Find(name, typ string) RecordSet {
+	var res RecordSet
+	for _, r := range rs {
+		if r.Name == name && r.Type == typ {
+			res = append(res, r)
+		}
+	}
+	return res
+}
+
+// Exists returns true if rs contains a record with the given name, type, and content.
+func (rs RecordSet) Exists(name, typ, content string) bool {
+	for _, r := range rs {
+		if r.Name == name && r.Type == typ && r.Content == content {
+			return true
+		}
+	}
+	return false
+}
+
+// ByType returns all records of the given type.
+func (rs RecordSet) ByType(typ string) RecordSet {
+	var res RecordSet
+	for _, r := range rs {
+		if r.Type == typ {
+			res = append(res, r)
+		}
+	}
+	return res
+}
+
+// CountByType returns the number of records of each type.
+func (rs RecordSet) CountByType() map[string]int {
+	counts := make(map[string]int)
+	for _, r := range rs {
+		counts[r.Type]++
+	}
+	return counts
+}
+
+// Grep returns all records that contain substr, ignoring case, in any field.
+func (rs RecordSet) Grep(substr string) RecordSet {
+	substr = strings.ToLower(substr)
+	var res RecordSet
+	for _, r := range rs {
+		for _, f := range []string{r.ID, r.Name, r.Type, r.Content, r.TTL, r.Prio, r.Notes} {
+			if strings.Contains(strings.ToLower(f), substr) {
+				res = append(res, r)
+				break
+			}
+		}
+	}
+	return res
+}
+
+// RecordChange is a record that differs between two RecordSets.
+type RecordChange struct {
+	Old, New *Record
+}
+
+// RecordSetDiff holds the differences between two RecordSets, see RecordSet.Diff.
+type RecordSetDiff struct {
+	Added    RecordSet
+	Removed  RecordSet
+	Modified []RecordChange
+}
+
+// Empty returns true if d contains no differences.
+func (d *RecordSetDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Modified) == 0
+}
+
+// Diff returns the records that were added, removed, and modified in newer
+// compared to rs. Records are matched by ID.
+func (rs RecordSet) Diff(newer RecordSet) *RecordSetDiff {
+	old := make(map[string]*Record, len(rs))
+	for _, r := range rs {
+		old[r.ID] = r
+	}
+	d := &RecordSetDiff{}
+	for _, r := range newer {
+		o, ok := old[r.ID]
+		if !ok {
+			d.Added = append(d.Added, r)
+			continue
+		}
+		delete(old, r.ID)
+		if *o != *r {
+			d.Modified = append(d.Modified, RecordChange{Old: o, New: r})
+		}
+	}
+	for _, r := range rs {
+		if _, ok := old[r.ID]; ok {
+			d.Removed = append(d.Removed, r)
+		}
+	}
+	return d
 }
 
 type CreateResponse struct {
@@ -51,6 +192,7 @@ type UpdateRequest struct {
 	// The subdomain for the record being created, not including the domain itself.
 	// Leave blank to create a record on the root domain.
 	// Use * to create a wildcard record.
+	// The client converts fully qualified names, see RelativeName.
 	Name string `json:"name"`
 
 	// The type of record being created.
@@ -63,16 +205,368 @@ type UpdateRequest struct {
 
 	// The time to live in seconds for the record.
 	// The minimum and the default is 600 seconds.
-	TTL string `json:"ttl"`
+	// Omitted if empty, which keeps the current value when editing.
+	TTL string `json:"ttl,omitempty"`
 
 	// (optional) The priority of the record for those that support it.
-	Prio string `json:"prio"`
+	// Omitted if empty, which keeps the current value when editing.
+	Prio string `json:"prio,omitempty"`
+
+	// (optional) Notes shown for the record in the Porkbun UI.
+	// Omitted if empty, which keeps the current value when editing.
+	Notes string `json:"notes,omitempty"`
 }
 
 type EditResponse struct {
 	Status
 }
 
+type DeleteRequest struct {
+	Keys
+}
+
+type SSLRequest struct {
+	Keys
+}
+
+// SSLBundle is the SSL certificate bundle of a domain.
+type SSLBundle struct {
+	Status
+	CertificateChain string `json:"certificatechain"`
+	PrivateKey       string `json:"privatekey"`
+	PublicKey        string `json:"publickey"`
+}
+
+type DomainsRequest struct {
+	Keys
+
+	// Index of the first domain to return. Porkbun returns at most 1000 domains
+	// per request, so increment by 1000 until an empty list is returned.
+	Start string `json:"start,omitempty"`
+}
+
+// DomainInfo describes a domain in the account, as returned by domain/listAll.
+// The listing does not contain nameservers, use Client.GetNameservers for those.
+type DomainInfo struct {
+	Domain       string `json:"domain"`
+	Status       string `json:"status"`
+	TLD          string `json:"tld"`
+	CreateDate   string `json:"createDate"`
+	ExpireDate   string `json:"expireDate"`
+	SecurityLock Flag   `json:"securityLock"`
+	WhoisPrivacy Flag   `json:"whoisPrivacy"`
+	AutoRenew    Flag   `json:"autoRenew"`
+	NotLocal     Flag   `json:"notLocal"`
+}
+
+type DomainsResponse struct {
+	Status
+	Domains []*DomainInfo `json:"domains"`
+}
+
+type NameserversRequest struct {
+	Keys
+}
+
+type NameserversResponse struct {
+	Status
+	NS []string `json:"ns"`
+}
+
+type UpdateNameserversRequest struct {
+	Keys
+	NS []string `json:"ns"`
+}
+
+type URLForwardRequest struct {
+	Keys
+
+	// The subdomain to forward. Leave blank to forward the root domain.
+	Subdomain string `json:"subdomain"`
+
+	// The URL to forward to.
+	Location string `json:"location"`
+
+	// The type of forward. Valid types are: temporary, permanent
+	Type string `json:"type"`
+
+	// Whether to include the URI path in the redirection ("yes" or "no").
+	IncludePath string `json:"includePath"`
+
+	// Whether to also forward all subdomains ("yes" or "no").
+	Wildcard string `json:"wildcard"`
+}
+
+type URLForward struct {
+	ID          string `json:"id"`
+	Subdomain   string `json:"subdomain"`
+	Location    string `json:"location"`
+	Type        string `json:"type"`
+	IncludePath string `json:"includePath"`
+	Wildcard    string `json:"wildcard"`
+}
+
+type URLForwardsRequest struct {
+	Keys
+}
+
+type URLForwardsResponse struct {
+	Status
+	Forwards []*URLForward `json:"forwards"`
+}
+
+// PricingRequest is empty: pricing/get does not require API keys.
+type PricingRequest struct{}
+
+// TLDPricing holds the prices (in USD) for a TLD.
+type TLDPricing struct {
+	Registration string `json:"registration"`
+	Renewal      string `json:"renewal"`
+	Transfer     string `json:"transfer"`
+}
+
+type PricingResponse struct {
+	Status
+	// Prices by TLD, e.g. "com".
+	Pricing map[string]TLDPricing `json:"pricing"`
+}
+
+// DNSSECRecord is a DS record published at the registry.
+type DNSSECRecord struct {
+	KeyTag     string `json:"keyTag"`
+	Algorithm  string `json:"alg"`
+	DigestType string `json:"digestType"`
+	Digest     string `json:"digest"`
+}
+
+type DNSSECCreateRequest struct {
+	Keys
+	DNSSECRecord
+}
+
+type DNSSECRecordsRequest struct {
+	Keys
+}
+
+type DNSSECRecordsResponse struct {
+	Status
+	// Records by key tag.
+	Records map[string]*DNSSECRecord `json:"records"`
+}
+
+type GlueRequest struct {
+	Keys
+	IPs []string `json:"ips"`
+}
+
+type GlueRecordsRequest struct {
+	Keys
+}
+
+// GlueRecord holds the IP addresses of a nameserver host of the domain.
+type GlueRecord struct {
+	Host string   `json:"host"`
+	V4   []string `json:"v4"`
+	V6   []string `json:"v6"`
+}
+
+type GlueRecordsResponse struct {
+	Status
+	Hosts []*GlueRecord `json:"hosts"`
+}
+
+// UnmarshalJSON decodes the [hostname, {"v4": [...], "v6": [...]}] pairs
+// that Porkbun uses for glue records.
+func (g *GlueRecord) UnmarshalJSON(data []byte) error {
+	var pair []json.RawMessage
+	if err := json.Unmarshal(data, &pair); err != nil {
+		return err
+	}
+	if len(pair) != 2 {
+		return fmt.Errorf("invalid glue record: %s", data)
+	}
+	var ips struct {
+		V4 []string `json:"v4"`
+		V6 []string `json:"v6"`
+	}
+	if err := json.Unmarshal(pair[0], &g.Host); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(pair[1], &ips); err != nil {
+		return err
+	}
+	g.V4, g.V6 = ips.V4, ips.V6
+	return nil
+}
+
+// UnmarshalJSON accepts numbers as well as strings for TTL and Prio,
+// e.g. ttl: 600 in YAML sync files, which are converted to JSON.
+func (r *UpdateRequest) UnmarshalJSON(data []byte) error {
+	type plain UpdateRequest
+	aux := struct {
+		*plain
+		TTL  numericString `json:"ttl"`
+		Prio numericString `json:"prio"`
+	}{(*plain)(r), numericString(r.TTL), numericString(r.Prio)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	r.TTL, r.Prio = string(aux.TTL), string(aux.Prio)
+	return nil
+}
+
+// numericString is a string that may also be encoded as a JSON number.
+type numericString string
+
+func (s *numericString) UnmarshalJSON(data []byte) error {
+	var v any
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	if err := d.Decode(&v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case nil:
+	case string:
+		*s = numericString(v)
+	case json.Number:
+		*s = numericString(v.String())
+	default:
+		return fmt.Errorf("invalid numeric value: %s", data)
+	}
+	return nil
+}
+
+// Flag is a boolean that Porkbun encodes inconsistently as 0/1, "0"/"1", or true/false.
+type Flag bool
+
+func (f *Flag) UnmarshalJSON(data []byte) error {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case nil:
+		*f = false
+	case bool:
+		*f = Flag(v)
+	case float64:
+		*f = v != 0
+	case string:
+		*f = v != "" && v != "0"
+	default:
+		return fmt.Errorf("invalid flag value: %s", data)
+	}
+	return nil
+}
+
+// RelativeName returns name relative to domain, as expected by the Porkbun API
+// for subdomains: "home.example.com" and "home.example.com." become "home",
+// and "example.com" and "example.com." become "" (the root domain).
+// Names that are not within domain, e.g. "home", are returned unchanged.
+// Names are compared case-insensitively.
+func RelativeName(name, domain string) string {
+	n := strings.TrimSuffix(name, ".")
+	domain = strings.TrimSuffix(domain, ".")
+	if strings.EqualFold(n, domain) {
+		return ""
+	}
+	if len(n) > len(domain) && strings.EqualFold(n[len(n)-len(domain):], domain) && n[len(n)-len(domain)-1] == '.' {
+		return n[:len(n)-len(domain)-1]
+	}
+	return name
+}
+
+// QuoteCharString returns s as a quoted zone file character-string, e.g. for the
+// value of a CAA record. Quotes and backslashes are escaped with a backslash,
+// and control characters and bytes that are not valid UTF-8 as \DDD.
+// Other non-ASCII characters are kept as is.
+func QuoteCharString(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == '"' || r == '\\':
+			sb.WriteByte('\\')
+			sb.WriteByte(s[i])
+		case r == utf8.RuneError && size == 1, r < 0x20, r == 0x7f:
+			fmt.Fprintf(&sb, "\\%03d", s[i])
+		default:
+			sb.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+// ValidRecordType returns true if typ is one of the RecordTypes.
+func ValidRecordType(typ string) bool {
+	return slices.Contains(RecordTypes, typ)
+}
+
+// DefaultTTL is the TTL in seconds that Porkbun uses for records without an explicit TTL.
+const DefaultTTL = 600
+
+// TTLSeconds returns the TTL of r in seconds. An empty TTL yields DefaultTTL.
+func (r *Record) TTLSeconds() (int, error) {
+	if r.TTL == "" {
+		return DefaultTTL, nil
+	}
+	ttl, err := strconv.Atoi(r.TTL)
+	if err != nil {
+		return 0, fmt.Errorf("invalid TTL %q: %v", r.TTL, err)
+	}
+	return ttl, nil
+}
+
+// Priority returns the priority of r. An empty priority yields 0.
+func (r *Record) Priority() (int, error) {
+	if r.Prio == "" {
+		return 0, nil
+	}
+	prio, err := strconv.Atoi(r.Prio)
+	if err != nil {
+		return 0, fmt.Errorf("invalid priority %q: %v", r.Prio, err)
+	}
+	return prio, nil
+}
+
+// MatchesDesired returns true if r already has the values requested by req,
+// so that no edit is needed:
+//
+//   - Type must be equal.
+//   - Content must be equal, ignoring a trailing dot.
+//   - Names must be equal relative to domain, ignoring case and a trailing dot,
+//     see RelativeName. req.Name may be a subdomain or fully qualified.
+//   - TTL, priority, and notes are only compared if set in req, since Porkbun
+//     keeps the current values for empty fields on edit. TTLs and priorities
+//     are compared numerically, and an empty r.TTL counts as DefaultTTL, so a
+//     requested TTL of "600" matches a record without an explicit TTL.
+func (r *Record) MatchesDesired(req *UpdateRequest, domain string) bool {
+	if r.Type != req.Type ||
+		strings.TrimSuffix(r.Content, ".") != strings.TrimSuffix(req.Content, ".") {
+		return false
+	}
+	if !strings.EqualFold(RelativeName(r.Name, domain), RelativeName(req.Name, domain)) {
+		return false
+	}
+	if req.TTL != "" {
+		ttl, err := r.TTLSeconds()
+		if want, err2 := strconv.Atoi(req.TTL); err != nil || err2 != nil || ttl != want {
+			return false
+		}
+	}
+	if req.Prio != "" {
+		prio, err := r.Priority()
+		if want, err2 := strconv.Atoi(req.Prio); err != nil || err2 != nil || prio != want {
+			return false
+		}
+	}
+	return req.Notes == "" || req.Notes == r.Notes
+}
+
 func (r *Record) String() string {
 	return fmt.Sprintf("%s %s %s %s %s (%s)", r.Name, r.Type, r.Content, r.TTL, r.Prio, r.ID)
 }
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRelativeName(t *testing.T) {
+	tests := []struct {
+		name, domain string
+		want         string
+	}{
+		{"home.example.com", "example.com", "home"},
+		{"home.example.com.", "example.com", "home"},
+		{"home.example.com", "example.com.", "home"},
+		{"a.b.example.com", "example.com", "a.b"},
+		{"Home.Example.COM", "example.com", "Home"},
+		{"example.com", "example.com", ""},
+		{"example.com.", "example.com", ""},
+		{"EXAMPLE.com", "example.com", ""},
+		{"", "example.com", ""},
+		{"home", "example.com", "home"},
+		{"_acme-challenge.www", "example.com", "_acme-challenge.www"},
+		{"example.org", "example.com", "example.org"},
+		{"notexample.com", "example.com", "notexample.com"},
+		{"home.example.com.evil.net", "example.com", "home.example.com.evil.net"},
+	}
+	for _, tc := range tests {
+		if got := RelativeName(tc.name, tc.domain); got != tc.want {
+			t.Errorf("RelativeName(%q, %q) = %q, want %q", tc.name, tc.domain, got, tc.want)
+		}
+	}
+}
+
+func TestKeysRedacted(t *testing.T) {
+	req := UpdateRequest{
+		Keys: Keys{SecretAPIKey: "sk1_secret", APIKey: "pk1_secret"},
+		Name: "www",
+		Type: "A",
+	}
+	for _, verb := range []string{"%v", "%+v", "%#v", "%s"} {
+		got := fmt.Sprintf(verb, req)
+		if strings.Contains(got, "_secret") {
+			t.Errorf("Sprintf(%q) = %s, leaks a key", verb, got)
+		}
+		if !strings.Contains(got, "www") {
+			t.Errorf("Sprintf(%q) = %s, want the other fields", verb, got)
+		}
+	}
+
+	b, err := json.Marshal(req.Keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"secretapikey":"sk1_secret","apikey":"pk1_secret"}`; string(b) != want {
+		t.Errorf("json.Marshal = %s, want %s", b, want)
+	}
+}
+
+func TestMatchesDesired(t *testing.T) {
+	r := &Record{Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: "600", Prio: "0"}
+	tests := []struct {
+		req  UpdateRequest
+		want bool
+	}{
+		{UpdateRequest{Name: "www", Type: "A", Content: "192.0.2.1"}, true},
+		{UpdateRequest{Name: "WWW", Type: "A", Content: "192.0.2.1"}, true},
+		{UpdateRequest{Name: "www.example.com.", Type: "A", Content: "192.0.2.1"}, true},
+		{UpdateRequest{Name: "www", Type: "A", Content: "192.0.2.1", TTL: "600"}, true},
+		{UpdateRequest{Name: "", Type: "A", Content: "192.0.2.1"}, false},
+		{UpdateRequest{Name: "ww", Type: "A", Content: "192.0.2.1"}, false},
+		{UpdateRequest{Name: "www.example", Type: "A", Content: "192.0.2.1"}, false},
+		{UpdateRequest{Name: "www", Type: "AAAA", Content: "192.0.2.1"}, false},
+		{UpdateRequest{Name: "www", Type: "A", Content: "192.0.2.2"}, false},
+		{UpdateRequest{Name: "www", Type: "A", Content: "192.0.2.1", TTL: "3600"}, false},
+		{UpdateRequest{Name: "www", Type: "A", Content: "192.0.2.1", Notes: "web"}, false},
+	}
+	for _, tc := range tests {
+		if got := r.MatchesDesired(&tc.req, "example.com"); got != tc.want {
+			t.Errorf("MatchesDesired(%+v) = %t, want %t", tc.req, got, tc.want)
+		}
+	}
+
+	apex := &Record{Name: "example.com", Type: "A", Content: "192.0.2.1"}
+	if !apex.MatchesDesired(&UpdateRequest{Type: "A", Content: "192.0.2.1"}, "example.com") {
+		t.Error("apex record does not match an empty name")
+	}
+}
+
+func TestQuoteCharString(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"letsencrypt.org", `"letsencrypt.org"`},
+		{`a"b\c`, `"a\"b\\c"`},
+		{"bücher", `"bücher"`},
+		{"tab\there", `"tab\009here"`},
+		{"caf\xe9", `"caf\233"`},
+		{"\x7f", `"\127"`},
+	}
+	for _, tc := range tests {
+		if got := QuoteCharString(tc.in); got != tc.want {
+			t.Errorf("QuoteCharString(%q) = %s, want %s", tc.in, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package porkbun
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/dnswlt/porkbun/pkg/api"
+	"gopkg.in/yaml.v3"
+)
+
+// ClientConfig holds the API keys and the domain to manage.
+//
+// To manage multiple domains with a single config, list them in Domains,
+// optionally with their own keys. Use DomainConfigs to obtain a
+// single-domain config for each of them.
+type ClientConfig struct {
+	Domain string `json:"domain"`
+	api.Keys
+
+	// Proxy is the URL of an http://, https://, or socks5:// proxy for all requests.
+	// If empty, the HTTP_PROXY, HTTPS_PROXY, and NO_PROXY environment variables are used.
+	Proxy string `json:"proxy,omitempty"`
+
+	Domains []DomainConfig `json:"domains,omitempty"`
+}
+
+// Environment variables that override ClientConfig fields in LoadClientConfig.
+const (
+	EnvDomain       = "PORKBUN_DOMAIN"
+	EnvAPIKey       = "PORKBUN_API_KEY"
+	EnvSecretAPIKey = "PORKBUN_SECRET_API_KEY"
+)
+
+// DomainConfig configures one of multiple domains in a ClientConfig.
+// Empty keys are inherited from the enclosing ClientConfig.
+type DomainConfig struct {
+	Domain string `json:"domain"`
+	api.Keys
+}
+
+// DomainConfigs returns a single-domain config for each configured domain:
+// the top-level Domain, if set, followed by all Domains.
+func (c *ClientConfig) DomainConfigs() []*ClientConfig {
+	var configs []*ClientConfig
+	if c.Domain != "" && !slices.ContainsFunc(c.Domains, func(d DomainConfig) bool { return d.Domain == c.Domain }) {
+		configs = append(configs, &ClientConfig{Domain: c.Domain, Keys: c.Keys, Proxy: c.Proxy})
+	}
+	for _, d := range c.Domains {
+		dc := &ClientConfig{Domain: d.Domain, Keys: c.Keys, Proxy: c.Proxy}
+		if d.APIKey != "" {
+			dc.APIKey = d.APIKey
+		}
+		if d.SecretAPIKey != "" {
+			dc.SecretAPIKey = d.SecretAPIKey
+		}
+		configs = append(configs, dc)
+	}
+	return configs
+}
+
+// ForDomain returns the single-domain config for domain.
+func (c *ClientConfig) ForDomain(domain string) (*ClientConfig, error) {
+	for _, dc := range c.DomainConfigs() {
+		if dc.Domain == domain {
+			return dc, nil
+		}
+	}
+	return nil, fmt.Errorf("domain %q is not configured", domain)
+}
+
+// Validate checks that all required fields of the config are set
+// and that all domains look like registrable domain names.
+func (c *ClientConfig) Validate() error {
+	if len(c.Domains) == 0 {
+		if err := c.validateDomain(); err != nil {
+			return fmt.Errorf("invalid config: %v", err)
+		}
+		return nil
+	}
+	var problems []string
+	for i, dc := range c.DomainConfigs() {
+		if err := dc.validateDomain(); err != nil {
+			name := dc.Domain
+			if name == "" {
+				name = fmt.Sprintf("domain #%d", i+1)
+			}
+			problems = append(problems, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid config: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// validateProxy checks that proxy is a URL with a supported proxy scheme.
+func validateProxy(proxy string) error {
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return fmt.Errorf("invalid proxy URL %q: %v", proxy, err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return fmt.Errorf("invalid proxy URL %q: scheme must be http, https, or socks5", proxy)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("invalid proxy URL %q: missing host", proxy)
+	}
+	return nil
+}
+
+// validateDomain validates the single-domain config c.
+func (c *ClientConfig) validateDomain() error {
+	var missing []string
+	if c.Domain == "" {
+		missing = append(missing, "domain")
+	}
+	if c.APIKey == "" {
+		missing = append(missing, "apikey")
+	}
+	if c.SecretAPIKey == "" {
+		missing = append(missing, "secretapikey")
+	}
+	var problems []string
+	if len(missing) > 0 {
+		problems = append(problems, "missing required fields: "+strings.Join(missing, ", "))
+	}
+	if c.Domain != "" {
+		if strings.Contains(c.Domain, "://") || strings.Contains(c.Domain, "/") {
+			problems = append(problems, fmt.Sprintf("domain %q must be a plain domain name, not a URL", c.Domain))
+		} else if !strings.Contains(strings.Trim(c.Domain, "."), ".") || !isHostname(c.Domain) {
+			problems = append(problems, fmt.Sprintf("domain %q is not a valid domain name", c.Domain))
+		}
+	}
+	if c.Proxy != "" {
+		if err := validateProxy(c.Proxy); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// StdinConfig is the config path that makes ReadClientConfig and LoadClientConfig
+// read the config from stdin instead of a file.
+const StdinConfig = "-"
+
+// ReadClientConfig reads and validates the config file at path.
+// The file is JSON, unless its extension is .yaml, .yml, or .toml.
+// If path is StdinConfig, the config is read from stdin.
+func ReadClientConfig(path string) (*ClientConfig, error) {
+	config, err := readClientConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func readClientConfig(path string) (*ClientConfig, error) {
+	var data []byte
+	var err error
+	if path == StdinConfig {
+		data, err = readStdin()
+	} else {
+		data, err = os.ReadFile(path)
+		if err != nil {
+			err = fmt.Errorf("failed to open config file: %w", err)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeClientConfig(data, filepath.Ext(path))
+}
+
+// decodeClientConfig decodes a config in the format given by the file extension ext,
+// see unmarshalByExt. YAML and TOML configs use the same field names as JSON configs.
+func decodeClientConfig(data []byte, ext string) (*ClientConfig, error) {
+	config := &ClientConfig{}
+	if err := unmarshalByExt(data, ext, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// unmarshalByExt decodes data into v in the format given by the file extension ext:
+// ".yaml" and ".yml" for YAML, ".toml" for TOML, and JSON otherwise.
+// YAML and TOML are converted to JSON first, so that the json struct tags of v apply.
+func unmarshalByExt(data []byte, ext string, v any) error {
+	format := "JSON"
+	var m any
+	switch strings.ToLower(ext) {
+	case ".yaml", ".yml":
+		format = "YAML"
+		if err := yaml.Unmarshal(data, &m); err != nil {
+			return fmt.Errorf("invalid YAML: %v", err)
+		}
+	case ".toml":
+		format = "TOML"
+		var t map[string]any
+		if err := toml.Unmarshal(data, &t); err != nil {
+			return fmt.Errorf("invalid TOML: %v", err)
+		}
+		m = t
+	}
+	if m != nil {
+		var err error
+		if data, err = json.Marshal(m); err != nil {
+			return fmt.Errorf("invalid %s: %v", format, err)
+		}
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("invalid %s: %v", format, err)
+	}
+	return nil
+}
+
+// readStdin reads all of stdin. It fails instead of blocking if stdin is
+// a terminal, since then no config is piped in. Otherwise it reads until EOF
+// without a deadline, so slow producers such as secret managers waiting for
+// an interactive unlock are fine.
+func readStdin() ([]byte, error) {
+	fi, err := os.Stdin.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config from stdin: %w", err)
+	}
+	if fi.Mode()&os.ModeCharDevice != 0 {
+		return nil, fmt.Errorf("no config on stdin: stdin is a terminal")
+	}
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config from stdin: %w", err)
+	}
+	return data, nil
+}
+
+// LoadClientConfig reads the config file at path and applies overrides
+// from the environment. The precedence order is, from highest to lowest:
+//
+//  1. The environment variables PORKBUN_DOMAIN, PORKBUN_API_KEY, and PORKBUN_SECRET_API_KEY,
+//     if set to a non-empty value.
+//  2. The values in the config file.
+//
+// A missing config file is not an error if at least one of the environment variables is set.
+// The resulting config is validated.
+func LoadClientConfig(path string) (*ClientConfig, error) {
+	config, err := readClientConfig(path)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) || !hasEnvConfig() {
+			return nil, err
+		}
+		config = &ClientConfig{}
+	}
+	if v := os.Getenv(EnvDomain); v != "" {
+		config.Domain = v
+	}
+	if v := os.Getenv(EnvAPIKey); v != "" {
+		config.APIKey = api.Secret(v)
+	}
+	if v := os.Getenv(EnvSecretAPIKey); v != "" {
+		config.SecretAPIKey = api.Secret(v)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func hasEnvConfig() bool {
+	return os.Getenv(EnvDomain) != "" || os.Getenv(EnvAPIKey) != "" || os.Getenv(EnvSecretAPIKey) != ""
+}
@@ -0,0 +1,66 @@
+package porkbun
+
+import (
+	"fmt"
+	"log"
+	"log/slog"
+)
+
+// Logger is the interface used by a Client to log its activity.
+type Logger interface {
+	Debugf(format string, args ...any)
+	Infof(format string, args ...any)
+	Errorf(format string, args ...any)
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debugf(format string, args ...any) {}
+func (nopLogger) Infof(format string, args ...any)  {}
+func (nopLogger) Errorf(format string, args ...any) {}
+
+type stdLogger struct {
+	l     *log.Logger
+	debug bool
+}
+
+// NewStdLogger returns a Logger that writes to l.
+// Debug messages are discarded unless debug is true.
+func NewStdLogger(l *log.Logger, debug bool) Logger {
+	return &stdLogger{l: l, debug: debug}
+}
+
+func (s *stdLogger) Debugf(format string, args ...any) {
+	if s.debug {
+		s.l.Printf("DEBUG "+format, args...)
+	}
+}
+
+func (s *stdLogger) Infof(format string, args ...any) {
+	s.l.Printf(format, args...)
+}
+
+func (s *stdLogger) Errorf(format string, args ...any) {
+	s.l.Printf("ERROR "+format, args...)
+}
+
+type slogLogger struct {
+	l *slog.Logger
+}
+
+// NewSlogLogger returns a Logger that writes to l at the corresponding slog levels.
+func NewSlogLogger(l *slog.Logger) Logger {
+	return &slogLogger{l: l}
+}
+
+func (s *slogLogger) Debugf(format string, args ...any) {
+	s.l.Debug(fmt.Sprintf(format, args...))
+}
+
+func (s *slogLogger) Infof(format string, args ...any) {
+	s.l.Info(fmt.Sprintf(format, args...))
+}
+
+func (s *slogLogger) Errorf(format string, args ...any) {
+	s.l.Error(fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,46 @@
+package porkbun
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// metricsRecorder records client metrics. Build with -tags prometheus
+// to get the Prometheus implementation, see WithMetrics.
+type metricsRecorder interface {
+	observeRequest(endpoint, outcome string, d time.Duration)
+	observeDynDNSUpdate(t time.Time)
+}
+
+// endpoint returns the API endpoint of url without its domain and ID arguments,
+// e.g. "dns/retrieve".
+func (c *Client) endpoint(url string) string {
+	path := strings.TrimPrefix(url, c.BaseURL)
+	elems := strings.SplitN(path, "/", 3)
+	if len(elems) > 2 {
+		elems = elems[:2]
+	}
+	return strings.Join(elems, "/")
+}
+
+// requestOutcome returns the metrics label for the result of a request.
+func requestOutcome(err error) string {
+	var apiErr *APIError
+	switch {
+	case err == nil:
+		return "success"
+	case errors.As(err, &apiErr):
+		return "api_error"
+	default:
+		return "error"
+	}
+}
+
+// ObserveDynDNSUpdate records the current time as the time of the last
+// successful dyndns update in the client's metrics, if any.
+func (c *Client) ObserveDynDNSUpdate() {
+	if c.metrics != nil {
+		c.metrics.observeDynDNSUpdate(time.Now())
+	}
+}
@@ -0,0 +1,55 @@
+//go:build prometheus
+
+package porkbun
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type promMetrics struct {
+	requests   *prometheus.CounterVec
+	latency    *prometheus.HistogramVec
+	lastUpdate prometheus.Gauge
+}
+
+func (m *promMetrics) observeRequest(endpoint, outcome string, d time.Duration) {
+	m.requests.WithLabelValues(endpoint, outcome).Inc()
+	m.latency.WithLabelValues(endpoint).Observe(d.Seconds())
+}
+
+func (m *promMetrics) observeDynDNSUpdate(t time.Time) {
+	m.lastUpdate.Set(float64(t.Unix()))
+}
+
+// WithMetrics registers metrics for the client's API requests in reg:
+//
+//   - porkbun_requests_total{endpoint,outcome}: number of requests.
+//   - porkbun_request_duration_seconds{endpoint}: request latency, including retries.
+//   - porkbun_last_dyndns_update_timestamp_seconds: time of the last
+//     successful dyndns update, see Client.ObserveDynDNSUpdate.
+//
+// Only one client can register its metrics in the same registry.
+// This option is only available when building with -tags prometheus.
+func WithMetrics(reg *prometheus.Registry) Option {
+	m := &promMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "porkbun_requests_total",
+			Help: "Number of Porkbun API requests by endpoint and outcome.",
+		}, []string{"endpoint", "outcome"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "porkbun_request_duration_seconds",
+			Help:    "Latency of Porkbun API requests by endpoint.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"endpoint"}),
+		lastUpdate: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "porkbun_last_dyndns_update_timestamp_seconds",
+			Help: "Unix time of the last successful dyndns update.",
+		}),
+	}
+	reg.MustRegister(m.requests, m.latency, m.lastUpdate)
+	return func(c *Client) {
+		c.metrics = m
+	}
+}
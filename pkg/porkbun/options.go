@@ -0,0 +1,174 @@
+package porkbun
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// DefaultRateLimit is the default number of requests per second sent by a Client.
+	DefaultRateLimit = 2
+	// DefaultRateBurst is the default number of requests a Client may send at once.
+	DefaultRateBurst = 1
+	// DefaultSlowRequestThreshold is the default duration above which a Client logs requests as slow.
+	DefaultSlowRequestThreshold = 3 * time.Second
+)
+
+// An Option configures optional settings of a Client.
+type Option func(*Client)
+
+// WithIPv4 makes the client use the IPv4-only API endpoint PorkbunApiV3Ipv4Url.
+// Ping then always reports the public IPv4 address of the host.
+func WithIPv4() Option {
+	return func(c *Client) {
+		c.BaseURL = PorkbunApiV3Ipv4Url
+	}
+}
+
+// WithBaseURL makes the client send requests to baseURL instead of the Porkbun API,
+// e.g. to a mock server or a proxy. baseURL must be an absolute http(s) URL;
+// a missing trailing slash is added. WithBaseURL panics if baseURL is invalid.
+func WithBaseURL(baseURL string) Option {
+	u, err := url.Parse(baseURL)
+	if err != nil || !u.IsAbs() || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		panic(fmt.Sprintf("porkbun: invalid base URL %q: must be an absolute http(s) URL", baseURL))
+	}
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+	return func(c *Client) {
+		c.BaseURL = baseURL
+	}
+}
+
+// WithDialFamily restricts the connections to the API to an IP family:
+// "ip4" for IPv4, "ip6" for IPv6, or "ip" for both (the default).
+// This is independent of the API endpoint (see WithIPv4), e.g. to verify
+// the IPv6 path to the dual-stack endpoint. It only takes effect if the
+// client's transport is an *http.Transport, which is then cloned.
+// WithDialFamily panics if family is invalid.
+func WithDialFamily(family string) Option {
+	var network string
+	switch family {
+	case "ip4":
+		network = "tcp4"
+	case "ip6":
+		network = "tcp6"
+	case "ip":
+		network = "tcp"
+	default:
+		panic(fmt.Sprintf("porkbun: invalid dial family %q: must be ip4, ip6, or ip", family))
+	}
+	return func(c *Client) {
+		c.dialNetwork = network
+	}
+}
+
+// WithHTTPClient makes the client send requests using hc,
+// e.g. to configure timeouts, proxies, or a custom transport.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.client = hc
+	}
+}
+
+// WithUserAgent sets the User-Agent header of all requests.
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithTTL sets the TTL in seconds of records created or edited by the client,
+// unless a request specifies its own TTL. It must be at least MinTTL.
+// By default, Porkbun's default TTL is used.
+func WithTTL(seconds int) Option {
+	return func(c *Client) {
+		c.ttl = strconv.Itoa(seconds)
+	}
+}
+
+// WithNotes sets the notes of records created or edited by the client,
+// unless a request specifies its own notes.
+func WithNotes(notes string) Option {
+	return func(c *Client) {
+		c.notes = notes
+	}
+}
+
+// WithTrace makes the client write each request body and raw response body to w,
+// for debugging. The client's API keys are redacted.
+func WithTrace(w io.Writer) Option {
+	return func(c *Client) {
+		c.trace = w
+		c.traceMu = new(sync.Mutex)
+	}
+}
+
+// WithLogger makes the client log requests (at debug level) and retries to logger.
+// By default, the client does not log anything.
+func WithLogger(logger Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// WithRetry makes the client retry failed requests up to maxAttempts times in total.
+// Only network errors and HTTP status 429 and 5xx responses are retried.
+// Requests that create records are only retried if Porkbun cannot have
+// processed them, i.e. if the connection failed or the status was 429.
+// The delay between attempts grows exponentially, starting at baseDelay,
+// and is jittered to avoid synchronized retries.
+// WithRetry panics if maxAttempts is less than 1 or baseDelay is not positive.
+func WithRetry(maxAttempts int, baseDelay time.Duration) Option {
+	if maxAttempts < 1 {
+		panic(fmt.Sprintf("porkbun: invalid retry attempts %d: must be at least 1", maxAttempts))
+	}
+	if baseDelay <= 0 {
+		panic(fmt.Sprintf("porkbun: invalid retry base delay %v: must be positive", baseDelay))
+	}
+	return func(c *Client) {
+		c.maxAttempts = maxAttempts
+		c.retryBaseDelay = baseDelay
+	}
+}
+
+// WithRequestTimeout limits each request attempt to d, independently of the
+// deadline of the context passed to the client's methods. The context still
+// caps the total time of a call, including all retries (see WithRetry):
+// an attempt that times out is retried if the context permits, while a call
+// whose context expires fails immediately. By default, attempts are only
+// limited by the context and the transport's timeouts.
+func WithRequestTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.requestTimeout = d
+	}
+}
+
+// WithSlowRequestThreshold makes the client log a warning (at info level, see WithLogger)
+// for each request whose response takes longer than d to arrive. Other requests are
+// logged at debug level with their duration. Set d to 0 to disable the warnings.
+// The default is DefaultSlowRequestThreshold.
+func WithSlowRequestThreshold(d time.Duration) Option {
+	return func(c *Client) {
+		c.slowThreshold = d
+	}
+}
+
+// WithRateLimit limits the rate of requests sent by the client to limit
+// requests per second, allowing bursts of up to burst requests.
+// Use rate.Inf to disable rate limiting.
+// The default is DefaultRateLimit requests per second with DefaultRateBurst.
+func WithRateLimit(limit rate.Limit, burst int) Option {
+	return func(c *Client) {
+		c.limiter = rate.NewLimiter(limit, burst)
+	}
+}
@@ -1,16 +1,27 @@
 package porkbun
 
 import (
+	"bufio"
 	"bytes"
 	"context"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand/v2"
+	"net"
 	"net/http"
 	"net/url"
-	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/dnswlt/porkbun/pkg/api"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/time/rate"
 )
 
 const (
@@ -18,41 +29,155 @@ const (
 	PorkbunApiV3Ipv4Url = "https://api-ipv4.porkbun.com/api/json/v3/"
 )
 
+// MinTTL is the minimum TTL in seconds that Porkbun accepts.
+const MinTTL = 600
+
 type Client struct {
 	BaseURL string
 	Config  *ClientConfig
 	client  *http.Client
+
+	userAgent string
+	logger    Logger
+
+	// Defaults of created and edited records, see WithTTL and WithNotes.
+	ttl   string
+	notes string
+
+	// Retry settings, see WithRetry.
+	maxAttempts    int
+	retryBaseDelay time.Duration
+
+	// Timeout of each request attempt, see WithRequestTimeout.
+	requestTimeout time.Duration
+
+	// Duration above which requests are logged as slow, see WithSlowRequestThreshold.
+	slowThreshold time.Duration
+
+	// Client-side rate limit, see WithRateLimit.
+	limiter *rate.Limiter
+
+	// Network of connections to the API, see WithDialFamily.
+	dialNetwork string
+
+	// Optional metrics, see WithMetrics.
+	metrics metricsRecorder
+
+	// Optional request and response dump, see WithTrace.
+	trace   io.Writer
+	traceMu *sync.Mutex // Shared by copies of the client, see pingVia.
 }
 
-type ClientConfig struct {
-	Domain string `json:"domain"`
-	api.Keys
+// newTransport returns the default transport of a Client, tuned for
+// long-running processes that make periodic calls to the same host.
+// If proxy is empty, the proxy is taken from the environment.
+// If proxy is invalid, all requests fail.
+func newTransport(proxy string) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if proxy != "" {
+		if err := validateProxy(proxy); err != nil {
+			// Fail closed instead of bypassing a misconfigured proxy.
+			t.Proxy = func(*http.Request) (*url.URL, error) { return nil, err }
+		} else {
+			u, _ := url.Parse(proxy)
+			t.Proxy = http.ProxyURL(u)
+		}
+	}
+	t.MaxIdleConnsPerHost = 4
+	t.IdleConnTimeout = 90 * time.Second
+	t.TLSHandshakeTimeout = 10 * time.Second
+	t.ResponseHeaderTimeout = 30 * time.Second
+	return t
 }
 
-func ReadClientConfig(path string) (*ClientConfig, error) {
-	f, err := os.Open(path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open config file: %v", err)
+// NewClient returns a client for the Porkbun API. Without options, the client uses
+// the dual-stack API endpoint PorkbunApiV3Url and a default rate limit.
+func NewClient(config *ClientConfig, opts ...Option) *Client {
+	c := &Client{
+		BaseURL:       PorkbunApiV3Url,
+		Config:        config,
+		client:        &http.Client{Transport: newTransport(config.Proxy)},
+		logger:        nopLogger{},
+		maxAttempts:   1,
+		limiter:       rate.NewLimiter(DefaultRateLimit, DefaultRateBurst),
+		slowThreshold: DefaultSlowRequestThreshold,
 	}
-	defer f.Close()
-	config := &ClientConfig{}
-	err = json.NewDecoder(f).Decode(config)
-	if err != nil {
-		return nil, fmt.Errorf("invalid JSON: %v", err)
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.dialNetwork != "" {
+		c.restrictDialNetwork()
 	}
-	return config, nil
+	return c
 }
 
-func NewClient(config *ClientConfig, useIPV4 bool) *Client {
-	url := PorkbunApiV3Url
-	if useIPV4 {
-		url = PorkbunApiV3Ipv4Url
+// restrictDialNetwork replaces the client's transport by a clone
+// that only dials connections of c.dialNetwork.
+func (c *Client) restrictDialNetwork() {
+	t, ok := c.client.Transport.(*http.Transport)
+	if !ok {
+		if c.client.Transport != nil {
+			return
+		}
+		t = http.DefaultTransport.(*http.Transport)
 	}
-	return &Client{
-		BaseURL: url,
-		Config:  config,
-		client:  &http.Client{},
+	t = t.Clone()
+	d := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	network := c.dialNetwork
+	t.DialContext = func(ctx context.Context, _, addr string) (net.Conn, error) {
+		return d.DialContext(ctx, network, addr)
 	}
+	hc := *c.client
+	hc.Transport = t
+	c.client = &hc
+}
+
+// HTTPClient returns the HTTP client used to send requests, see WithHTTPClient.
+// Callers can use it for related requests that should share its transport settings.
+func (c *Client) HTTPClient() *http.Client {
+	return c.client
+}
+
+// NewClients returns a client for each domain in config.
+func NewClients(config *ClientConfig, opts ...Option) []*Client {
+	var clients []*Client
+	for _, dc := range config.DomainConfigs() {
+		clients = append(clients, NewClient(dc, opts...))
+	}
+	return clients
+}
+
+// APIError is returned for requests that Porkbun rejected, either with a non-200
+// HTTP status or with an "ERROR" status in the response body.
+type APIError struct {
+	// Status is the "status" field of the response, usually "ERROR".
+	// Empty if the response was not valid JSON.
+	Status string
+	// Message is Porkbun's error message, or the raw response body
+	// if the response did not contain a message.
+	Message string
+	// HTTPStatus is the HTTP status code of the response.
+	HTTPStatus int
+}
+
+// NonJSONError is returned if Porkbun responds with something other than JSON,
+// typically an HTML error page during maintenance. Requests failing with
+// a NonJSONError with HTTP status 429 or 5xx are retried (see WithRetry).
+type NonJSONError struct {
+	HTTPStatus  int
+	ContentType string
+}
+
+func (e *NonJSONError) Error() string {
+	return fmt.Sprintf("Porkbun returned a non-JSON response (status %d %s, content type %q), the API may be down",
+		e.HTTPStatus, http.StatusText(e.HTTPStatus), e.ContentType)
+}
+
+func (e *APIError) Error() string {
+	if e.HTTPStatus != http.StatusOK {
+		return fmt.Sprintf("response status %d %s: %s", e.HTTPStatus, http.StatusText(e.HTTPStatus), e.Message)
+	}
+	return fmt.Sprintf("response status %s: %s", e.Status, e.Message)
 }
 
 func (c *Client) url(elem ...string) string {
@@ -63,33 +188,235 @@ func (c *Client) url(elem ...string) string {
 	return p
 }
 
-func doRequest[Resp any, Req any](c *Client, ctx context.Context, url string, req *Req) (*Resp, error) {
-	var buf bytes.Buffer
-	err := json.NewEncoder(&buf).Encode(req)
-	if err != nil {
-		return nil, fmt.Errorf("cannot marshal request: %v", err)
+// statusCarrier is implemented by all response types that embed api.Status.
+type statusCarrier interface {
+	GetStatus() api.Status
+}
+
+// isRetryable returns true if a request that failed with err may succeed when retried:
+// for network errors, and for HTTP status 429 and 5xx responses.
+func isRetryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var apiErr *APIError
+	var nonJSON *NonJSONError
+	var opErr *net.OpError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &apiErr):
+		return retryableStatus(apiErr.HTTPStatus)
+	case errors.As(err, &nonJSON):
+		return retryableStatus(nonJSON.HTTPStatus)
+	case errors.As(err, &opErr):
+		// Failed to dial, read, or write.
+		return true
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return true
 	}
-	r, err := http.NewRequestWithContext(ctx, "POST", url, &buf)
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+func retryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// notProcessed returns true if err proves that Porkbun did not process the request:
+// the connection could not be established, or the request was rate limited.
+func notProcessed(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.HTTPStatus == http.StatusTooManyRequests
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// nonIdempotent are the endpoints whose requests must not be retried once
+// Porkbun may have processed them, since a retry could create a duplicate.
+var nonIdempotent = map[string]bool{
+	"dns/create":           true,
+	"domain/addUrlForward": true,
+}
+
+// backoff returns the jittered delay before the given retry (1 for the first retry).
+func (c *Client) backoff(retry int) time.Duration {
+	d := c.retryBaseDelay << (retry - 1)
+	// Jitter within [d/2, 3d/2).
+	return d/2 + rand.N(d+1)
+}
+
+// post sends body to url and returns the response body if the response status was 200.
+// Retryable failures are retried according to the client's retry settings.
+func (c *Client) post(ctx context.Context, url string, body []byte) ([]byte, error) {
+	var respBody []byte
+	err := c.retry(ctx, url, func(ctx context.Context) (err error) {
+		respBody, err = c.postOnce(ctx, url, body)
+		return err
+	}, func(err error) bool {
+		if nonIdempotent[c.endpoint(url)] && !notProcessed(err) {
+			return false
+		}
+		return isRetryable(ctx, err)
+	})
+	return respBody, err
+}
+
+// retry calls attempt until it succeeds, fails with an error for which retryable
+// returns false, or the client's retry settings are exhausted. Each attempt gets
+// its own context with the client's request timeout, if any.
+func (c *Client) retry(ctx context.Context, url string, attempt func(context.Context) error, retryable func(error) bool) error {
+	for n := 1; ; n++ {
+		attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+		if c.requestTimeout > 0 {
+			attemptCtx, cancel = context.WithTimeout(ctx, c.requestTimeout)
+		}
+		err := attempt(attemptCtx)
+		cancel()
+		if err == nil || n >= c.maxAttempts || !retryable(err) {
+			return err
+		}
+		delay := c.backoff(n)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return err
+		}
+		c.logger.Infof("Request to %s failed (attempt %d of %d), retrying in %v: %v",
+			url, n, c.maxAttempts, delay.Round(time.Millisecond), err)
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+	}
+}
+
+// tracef writes to the WithTrace writer, if any.
+func (c *Client) tracef(format string, args ...any) {
+	if c.trace == nil {
+		return
+	}
+	c.traceMu.Lock()
+	defer c.traceMu.Unlock()
+	fmt.Fprintf(c.trace, format, args...)
+}
+
+// redact returns b with all occurrences of the client's API keys replaced by "***".
+func (c *Client) redact(b []byte) []byte {
+	for _, key := range []api.Secret{c.Config.SecretAPIKey, c.Config.APIKey} {
+		if key != "" {
+			b = bytes.ReplaceAll(b, []byte(key), []byte("***"))
+		}
+	}
+	return b
+}
+
+// send posts body to url and returns the response. Callers must close its body.
+func (c *Client) send(ctx context.Context, url string, body []byte) (*http.Response, error) {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return nil, fmt.Errorf("rate limit: %w", err)
+	}
+	r, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %v", err)
 	}
+	c.logger.Debugf("POST %s", url)
+	c.tracef("> POST %s\n%s\n", url, bytes.TrimSpace(c.redact(body)))
+	if c.userAgent != "" {
+		r.Header.Set("User-Agent", c.userAgent)
+	}
+	start := time.Now()
 	response, err := c.client.Do(r)
+	elapsed := time.Since(start).Round(time.Millisecond)
 	if err != nil {
+		c.logger.Debugf("POST %s failed after %v: %v", url, elapsed, err)
 		return nil, fmt.Errorf("POST failed: %w", err)
 	}
+	if c.slowThreshold > 0 && elapsed > c.slowThreshold {
+		c.logger.Infof("Warning: slow request: POST %s: %s after %v (threshold %v)",
+			url, response.Status, elapsed, c.slowThreshold)
+	} else {
+		c.logger.Debugf("POST %s: %s after %v", url, response.Status, elapsed)
+	}
+	return response, nil
+}
+
+func (c *Client) postOnce(ctx context.Context, url string, body []byte) ([]byte, error) {
+	response, err := c.send(ctx, url, body)
+	if err != nil {
+		return nil, err
+	}
 	defer response.Body.Close()
+	respBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("response status %s (could not read response body: %w)", response.Status, err)
+	}
+	c.tracef("< %s\n%s\n", response.Status, c.redact(respBody))
+	if err := c.checkResponse(response, respBody); err != nil {
+		return nil, err
+	}
+	return respBody, nil
+}
+
+// isHTML returns true if a response with the given content type and body
+// (or a prefix of it) is an HTML page rather than JSON.
+func isHTML(contentType string, body []byte) bool {
+	return strings.Contains(contentType, "html") || bytes.HasPrefix(bytes.TrimSpace(body), []byte("<"))
+}
+
+// checkResponse returns a NonJSONError or an APIError if response, whose body
+// is respBody, is not a JSON response with status 200.
+func (c *Client) checkResponse(response *http.Response, respBody []byte) error {
+	if ct := response.Header.Get("Content-Type"); isHTML(ct, respBody) {
+		return &NonJSONError{HTTPStatus: response.StatusCode, ContentType: ct}
+	}
 	if response.StatusCode != http.StatusOK {
-		body, err := io.ReadAll(response.Body)
-		if err != nil {
-			return nil, fmt.Errorf("response status %s (could not read response body: %v)", response.Status, err)
+		// Porkbun reports errors (e.g. invalid record IDs) as a JSON status message.
+		apiErr := &APIError{
+			Message:    string(c.redact(respBody)),
+			HTTPStatus: response.StatusCode,
+		}
+		var status api.Status
+		if json.Unmarshal(respBody, &status) == nil && status.Message != "" {
+			apiErr.Status = status.Status
+			apiErr.Message = string(c.redact([]byte(status.Message)))
 		}
-		return nil, fmt.Errorf("response status %s. Body: %v)", response.Status, string(body))
+		return apiErr
+	}
+	return nil
+}
+
+func doRequest[Resp any, Req any](c *Client, ctx context.Context, url string, req *Req) (_ *Resp, err error) {
+	if c.metrics != nil {
+		defer func(start time.Time) {
+			c.metrics.observeRequest(c.endpoint(url), requestOutcome(err), time.Since(start))
+		}(time.Now())
+	}
+	var buf bytes.Buffer
+	err = json.NewEncoder(&buf).Encode(req)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal request: %v", err)
+	}
+	body, err := c.post(ctx, url, buf.Bytes())
+	if err != nil {
+		return nil, err
 	}
 	resp := new(Resp)
-	err = json.NewDecoder(response.Body).Decode(resp)
+	err = json.Unmarshal(body, resp)
 	if err != nil {
 		return nil, fmt.Errorf("cannot unmarshal response: %v", err)
 	}
+	// Porkbun returns HTTP status 200 for some errors, e.g. invalid API keys.
+	if sc, ok := any(resp).(statusCarrier); ok {
+		if status := sc.GetStatus(); status.Status == api.StatusError {
+			return nil, &APIError{
+				Status:     status.Status,
+				Message:    string(c.redact([]byte(status.Message))),
+				HTTPStatus: http.StatusOK,
+			}
+		}
+	}
 	return resp, nil
 }
 
@@ -100,35 +427,911 @@ func (c *Client) Ping(ctx context.Context) (*api.PingResponse, error) {
 	return doRequest[api.PingResponse](c, ctx, c.url("ping"), &req)
 }
 
+// PublicIPs are the public IP addresses of the host, as returned by PingBoth.
+type PublicIPs struct {
+	// IPv4 and IPv6 are empty if the address could not be determined.
+	IPv4, IPv6 string
+	// IPv4Err and IPv6Err are the reasons why an address could not be determined.
+	IPv4Err, IPv6Err error
+}
+
+// PingBoth returns the public IPv4 and IPv6 addresses of the host, as reported by
+// pinging the IPv4-only endpoint PorkbunApiV3Ipv4Url and the dual-stack endpoint
+// PorkbunApiV3Url over IPv6, regardless of which of them is the client's BaseURL.
+// A custom BaseURL (see WithBaseURL) is used for both families instead.
+// It only fails if neither address can be determined, e.g. on hosts without
+// IPv6 connectivity only IPv6Err is set.
+func (c *Client) PingBoth(ctx context.Context) (*PublicIPs, error) {
+	v4URL, v6URL := PorkbunApiV3Ipv4Url, PorkbunApiV3Url
+	if c.BaseURL != PorkbunApiV3Url && c.BaseURL != PorkbunApiV3Ipv4Url {
+		v4URL, v6URL = c.BaseURL, c.BaseURL
+	}
+	ips := &PublicIPs{}
+	ips.IPv4, ips.IPv4Err = c.pingVia(ctx, v4URL, "tcp4")
+	ips.IPv6, ips.IPv6Err = c.pingVia(ctx, v6URL, "tcp6")
+	if ips.IPv4Err != nil && ips.IPv6Err != nil {
+		return nil, fmt.Errorf("cannot determine public IP: %w", errors.Join(ips.IPv4Err, ips.IPv6Err))
+	}
+	return ips, nil
+}
+
+// pingVia pings baseURL over connections of network and returns the reported IP.
+// The request shares the client's settings, including its rate limiter.
+func (c *Client) pingVia(ctx context.Context, baseURL, network string) (string, error) {
+	pc := *c
+	pc.BaseURL = baseURL
+	pc.dialNetwork = network
+	pc.restrictDialNetwork()
+	resp, err := pc.Ping(ctx)
+	if err != nil {
+		return "", err
+	}
+	ip := net.ParseIP(resp.YourIP)
+	if ip == nil || (ip.To4() != nil) != (network == "tcp4") {
+		return "", fmt.Errorf("ping via %s returned unexpected IP %q", network, resp.YourIP)
+	}
+	return resp.YourIP, nil
+}
+
+func validateRecordType(typ string) error {
+	if !api.ValidRecordType(typ) {
+		return fmt.Errorf("invalid record type %q, must be one of %s",
+			typ, strings.Join(api.RecordTypes, ", "))
+	}
+	return nil
+}
+
+// withDefaults sets the client's default TTL and notes in rec, if rec has none,
+// and validates the resulting TTL.
+func (c *Client) withDefaults(rec *api.UpdateRequest) error {
+	if rec.Notes == "" {
+		rec.Notes = c.notes
+	}
+	if rec.TTL == "" {
+		rec.TTL = c.ttl
+	}
+	if rec.TTL == "" {
+		return nil
+	}
+	ttl, err := strconv.Atoi(rec.TTL)
+	if err != nil {
+		return fmt.Errorf("invalid TTL %q: %v", rec.TTL, err)
+	}
+	if ttl < MinTTL {
+		return fmt.Errorf("TTL must be at least %d seconds, got %d", MinTTL, ttl)
+	}
+	return nil
+}
+
+// CreateRecord creates a new DNS record. The Keys of rec are ignored
+// and replaced by the client's configured keys. rec.Name may also be
+// fully qualified, see api.RelativeName.
+func (c *Client) CreateRecord(ctx context.Context, rec api.UpdateRequest) (*api.CreateResponse, error) {
+	if err := validateRecordType(rec.Type); err != nil {
+		return nil, err
+	}
+	if err := c.withDefaults(&rec); err != nil {
+		return nil, err
+	}
+	rec.Name = api.RelativeName(rec.Name, c.Config.Domain)
+	rec.Keys = c.Config.Keys
+	return doRequest[api.CreateResponse](c, ctx, c.url("dns/create", c.Config.Domain), &rec)
+}
+
+// CreateAndGet creates rec like CreateRecord, then retrieves and returns the created
+// record, with the name, TTL, and priority as normalized by Porkbun.
+// If only the retrieval fails, the error contains the ID of the created record.
+func (c *Client) CreateAndGet(ctx context.Context, rec api.UpdateRequest) (*api.Record, error) {
+	resp, err := c.CreateRecord(ctx, rec)
+	if err != nil {
+		return nil, err
+	}
+	r, err := c.RetrieveByID(ctx, resp.ID)
+	if err != nil {
+		return nil, fmt.Errorf("created record %s, but cannot retrieve it: %w", resp.ID, err)
+	}
+	return r, nil
+}
+
+// CreateIfAbsent creates rec unless a record with the same name, type, and content
+// exists already. TTL, priority, and notes are not compared. It returns the ID of
+// the created or existing record, and true if the record was created.
+func (c *Client) CreateIfAbsent(ctx context.Context, rec api.UpdateRequest) (*api.CreateResponse, bool, error) {
+	if err := validateRecordType(rec.Type); err != nil {
+		return nil, false, err
+	}
+	existing, err := c.RetrieveByNameType(ctx, rec.Type, rec.Name)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, r := range existing.Records {
+		if strings.TrimSuffix(r.Content, ".") == strings.TrimSuffix(rec.Content, ".") {
+			c.logger.Infof("%s record %s with content %q exists already (ID %s), not creating it",
+				rec.Type, r.Name, rec.Content, r.ID)
+			return &api.CreateResponse{Status: existing.Status, ID: r.ID}, false, nil
+		}
+	}
+	resp, err := c.CreateRecord(ctx, rec)
+	if err != nil {
+		return nil, false, err
+	}
+	return resp, true, nil
+}
+
+// EditRecord edits the record with the given ID. Empty TTL, Prio, and Notes
+// fields of rec are not sent to Porkbun, unless the client has a default
+// (see WithTTL and WithNotes). As in CreateRecord, rec.Name may be fully qualified.
+func (c *Client) EditRecord(ctx context.Context, id string, rec api.UpdateRequest) (*api.EditResponse, error) {
+	if id == "" {
+		return nil, fmt.Errorf("record ID must not be empty")
+	}
+	if err := validateRecordType(rec.Type); err != nil {
+		return nil, err
+	}
+	if err := c.withDefaults(&rec); err != nil {
+		return nil, err
+	}
+	rec.Name = api.RelativeName(rec.Name, c.Config.Domain)
+	rec.Keys = c.Config.Keys
+	return doRequest[api.EditResponse](c, ctx, c.url("dns/edit", c.Config.Domain, id), &rec)
+}
+
+// SetNotes sets the notes of the record with the given ID. Since the edit endpoint
+// replaces the whole record, SetNotes retrieves the record first and edits it
+// with the same name, type, content, TTL, and priority. Empty notes are omitted
+// from the request and thus cannot be used to clear the notes.
+func (c *Client) SetNotes(ctx context.Context, id, notes string) (*api.EditResponse, error) {
+	r, err := c.RetrieveByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return c.EditRecord(ctx, id, api.UpdateRequest{
+		Name:    api.RelativeName(r.Name, c.Config.Domain),
+		Type:    r.Type,
+		Content: r.Content,
+		TTL:     r.TTL,
+		Prio:    r.Prio,
+		Notes:   notes,
+	})
+}
+
 func (c *Client) CreateA(ctx context.Context, subdomain string, ipv4Address string) (*api.CreateResponse, error) {
-	req := api.UpdateRequest{
-		Keys:    c.Config.Keys,
+	return c.CreateRecord(ctx, api.UpdateRequest{
 		Name:    subdomain,
 		Type:    "A",
 		Content: ipv4Address,
-		// Use defaults for TTL and Prio
-	}
-	return doRequest[api.CreateResponse](c, ctx, c.url("dns/create"), &req)
+	})
 }
 
-func (c *Client) EditAllA(ctx context.Context, subdomain string, ipv4Address string) (*api.EditResponse, error) {
+func (c *Client) CreateAAAA(ctx context.Context, subdomain string, ipv6Address string) (*api.CreateResponse, error) {
+	return c.CreateRecord(ctx, api.UpdateRequest{
+		Name:    subdomain,
+		Type:    "AAAA",
+		Content: ipv6Address,
+	})
+}
+
+func (c *Client) editByNameType(ctx context.Context, recordType, subdomain, content string) (*api.EditResponse, error) {
+	subdomain = api.RelativeName(subdomain, c.Config.Domain)
 	req := api.UpdateRequest{
 		Keys:    c.Config.Keys,
-		Content: ipv4Address,
+		Content: content,
+	}
+	if err := c.withDefaults(&req); err != nil {
+		return nil, err
 	}
 	var u string
 	if subdomain == "" {
-		u = c.url("dns/editByNameType", c.Config.Domain, "A")
+		u = c.url("dns/editByNameType", c.Config.Domain, recordType)
 	} else {
-		u = c.url("dns/editByNameType", c.Config.Domain, "A", subdomain)
+		u = c.url("dns/editByNameType", c.Config.Domain, recordType, subdomain)
 	}
 	return doRequest[api.EditResponse](c, ctx, u, &req)
 }
 
+// EditByNameTypePreserve sets the content of all recordType records of subdomain
+// to rec.Content. Unlike EditAllA and similar methods, it edits the records one
+// by one and keeps the TTL, priority, and notes of each record, unless they
+// are set in rec. It returns the number of edited records.
+func (c *Client) EditByNameTypePreserve(ctx context.Context, recordType, subdomain string, rec api.UpdateRequest) (int, error) {
+	existing, err := c.RetrieveByNameType(ctx, recordType, subdomain)
+	if err != nil {
+		return 0, err
+	}
+	edited := 0
+	var errs []error
+	for _, r := range existing.Records {
+		req := rec
+		req.Name = subdomain
+		req.Type = recordType
+		if req.TTL == "" {
+			req.TTL = r.TTL
+		}
+		if req.Prio == "" {
+			req.Prio = r.Prio
+		}
+		if req.Notes == "" {
+			req.Notes = r.Notes
+		}
+		if _, err := c.EditRecord(ctx, r.ID, req); err != nil {
+			errs = append(errs, fmt.Errorf("record %s: %w", r.ID, err))
+			continue
+		}
+		edited++
+	}
+	return edited, errors.Join(errs...)
+}
+
+func (c *Client) EditAllA(ctx context.Context, subdomain string, ipv4Address string) (*api.EditResponse, error) {
+	return c.editByNameType(ctx, "A", subdomain, ipv4Address)
+}
+
+func (c *Client) EditAllAAAA(ctx context.Context, subdomain string, ipv6Address string) (*api.EditResponse, error) {
+	return c.editByNameType(ctx, "AAAA", subdomain, ipv6Address)
+}
+
+// EditIfChanged is like EditAllA and EditAllAAAA for any recordType, but first
+// retrieves the records and skips the edit if they all already have the
+// content (and the client's TTL and notes, if set). It returns true if
+// the records were edited. If there are no such records, nothing is edited.
+func (c *Client) EditIfChanged(ctx context.Context, recordType, subdomain, content string) (bool, error) {
+	existing, err := c.RetrieveByNameType(ctx, recordType, subdomain)
+	if err != nil {
+		return false, err
+	}
+	req := api.UpdateRequest{
+		Name:    subdomain,
+		Type:    recordType,
+		Content: content,
+	}
+	if err := c.withDefaults(&req); err != nil {
+		return false, err
+	}
+	changed := false
+	for _, r := range existing.Records {
+		if !r.MatchesDesired(&req, c.Config.Domain) {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return false, nil
+	}
+	if _, err := c.editByNameType(ctx, recordType, subdomain, content); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RetrieveAll returns all DNS records of the domain.
+//
+// The dns/retrieve endpoint does not paginate, so a response that was truncated
+// by the server cannot be detected reliably. As a guard, RetrieveAll logs a
+// message if the number of records is a suspiciously round number.
 func (c *Client) RetrieveAll(ctx context.Context) (*api.RecordsResponse, error) {
 	req := api.RecordsRequest{
 		Keys: c.Config.Keys,
 	}
 	url := c.url("dns/retrieve", c.Config.Domain)
-	return doRequest[api.RecordsResponse](c, ctx, url, &req)
+	resp, err := doRequest[api.RecordsResponse](c, ctx, url, &req)
+	if err != nil {
+		return nil, err
+	}
+	if n := len(resp.Records); n >= 100 && n%100 == 0 {
+		c.logger.Infof("Warning: retrieved exactly %d records for %s, the response may be truncated",
+			n, c.Config.Domain)
+	}
+	return resp, nil
+}
+
+// StreamRecords writes all DNS records of the domain to w as JSON Lines,
+// one record per line, and returns the number of records written.
+// Records are decoded from the response body and written one at a time
+// instead of materializing the full record set. Failed requests are
+// retried as usual, but only until the first record was written.
+func (c *Client) StreamRecords(ctx context.Context, w io.Writer) (n int, err error) {
+	url := c.url("dns/retrieve", c.Config.Domain)
+	if c.metrics != nil {
+		defer func(start time.Time) {
+			c.metrics.observeRequest(c.endpoint(url), requestOutcome(err), time.Since(start))
+		}(time.Now())
+	}
+	req := api.RecordsRequest{
+		Keys: c.Config.Keys,
+	}
+	body, err := json.Marshal(&req)
+	if err != nil {
+		return 0, fmt.Errorf("cannot marshal request: %v", err)
+	}
+	err = c.retry(ctx, url, func(ctx context.Context) (err error) {
+		n, err = c.streamOnce(ctx, url, body, w)
+		return err
+	}, func(err error) bool {
+		return n == 0 && isRetryable(ctx, err)
+	})
+	return n, err
+}
+
+func (c *Client) streamOnce(ctx context.Context, url string, body []byte, w io.Writer) (int, error) {
+	response, err := c.send(ctx, url, body)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	br := bufio.NewReader(response.Body)
+	// Skip leading whitespace to detect HTML pages like checkResponse does.
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			break
+		}
+		if b != ' ' && b != '\t' && b != '\n' && b != '\r' {
+			br.UnreadByte()
+			break
+		}
+	}
+	prefix, _ := br.Peek(1)
+	if response.StatusCode != http.StatusOK || isHTML(response.Header.Get("Content-Type"), prefix) {
+		respBody, err := io.ReadAll(br)
+		if err != nil {
+			return 0, fmt.Errorf("response status %s (could not read response body: %w)", response.Status, err)
+		}
+		c.tracef("< %s\n%s\n", response.Status, c.redact(respBody))
+		return 0, c.checkResponse(response, respBody)
+	}
+	var r io.Reader = br
+	if c.trace != nil {
+		var traced bytes.Buffer
+		r = io.TeeReader(br, &traced)
+		defer func() { c.tracef("< %s\n%s\n", response.Status, c.redact(traced.Bytes())) }()
+	}
+	return c.decodeRecords(r, w)
+}
+
+// decodeRecords decodes a dns/retrieve response from r and writes its records to w
+// as JSON Lines. It returns the number of records written.
+func (c *Client) decodeRecords(r io.Reader, w io.Writer) (int, error) {
+	dec := json.NewDecoder(r)
+	enc := json.NewEncoder(w)
+	if err := expectDelim(dec, '{'); err != nil {
+		return 0, err
+	}
+	var status api.Status
+	n := 0
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return n, fmt.Errorf("cannot unmarshal response: %v", err)
+		}
+		switch tok {
+		case "status":
+			err = dec.Decode(&status.Status)
+		case "message":
+			err = dec.Decode(&status.Message)
+		case "records":
+			if err := expectDelim(dec, '['); err != nil {
+				return n, err
+			}
+			for dec.More() {
+				var rec api.Record
+				if err := dec.Decode(&rec); err != nil {
+					return n, fmt.Errorf("cannot unmarshal record: %v", err)
+				}
+				if err := enc.Encode(&rec); err != nil {
+					return n, err
+				}
+				n++
+			}
+			err = expectDelim(dec, ']')
+		default:
+			err = dec.Decode(new(json.RawMessage))
+		}
+		if err != nil {
+			return n, fmt.Errorf("cannot unmarshal response: %v", err)
+		}
+	}
+	if status.Status == api.StatusError {
+		return n, &APIError{
+			Status:     status.Status,
+			Message:    string(c.redact([]byte(status.Message))),
+			HTTPStatus: http.StatusOK,
+		}
+	}
+	return n, nil
+}
+
+// expectDelim reads the next token from dec and checks that it is delim.
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("cannot unmarshal response: %v", err)
+	}
+	if tok != delim {
+		return fmt.Errorf("cannot unmarshal response: expected %v, got %v", delim, tok)
+	}
+	return nil
+}
+
+func (c *Client) DeleteRecord(ctx context.Context, id string) (*api.EditResponse, error) {
+	if id == "" {
+		return nil, fmt.Errorf("record ID must not be empty")
+	}
+	req := api.DeleteRequest{
+		Keys: c.Config.Keys,
+	}
+	url := c.url("dns/delete", c.Config.Domain, id)
+	return doRequest[api.EditResponse](c, ctx, url, &req)
+}
+
+// DeleteRecords deletes the records with the given IDs, sending at most
+// concurrency requests at a time. The client's rate limit still applies.
+// The returned slice contains the error of each ID at the same index, or nil
+// if its record was deleted. The returned error is non-nil if any deletion failed.
+func (c *Client) DeleteRecords(ctx context.Context, ids []string, concurrency int) ([]error, error) {
+	errs := make([]error, len(ids))
+	var g errgroup.Group
+	g.SetLimit(max(concurrency, 1))
+	for i, id := range ids {
+		g.Go(func() error {
+			_, errs[i] = c.DeleteRecord(ctx, id)
+			return nil
+		})
+	}
+	g.Wait()
+	failed := 0
+	for _, err := range errs {
+		if err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return errs, fmt.Errorf("failed to delete %d of %d records", failed, len(ids))
+	}
+	return errs, nil
+}
+
+func (c *Client) DeleteByNameType(ctx context.Context, recordType, subdomain string) (*api.EditResponse, error) {
+	subdomain = api.RelativeName(subdomain, c.Config.Domain)
+	req := api.DeleteRequest{
+		Keys: c.Config.Keys,
+	}
+	var u string
+	if subdomain == "" {
+		u = c.url("dns/deleteByNameType", c.Config.Domain, recordType)
+	} else {
+		u = c.url("dns/deleteByNameType", c.Config.Domain, recordType, subdomain)
+	}
+	return doRequest[api.EditResponse](c, ctx, u, &req)
+}
+
+func (c *Client) RetrieveByNameType(ctx context.Context, recordType, subdomain string) (*api.RecordsResponse, error) {
+	subdomain = api.RelativeName(subdomain, c.Config.Domain)
+	req := api.RecordsRequest{
+		Keys: c.Config.Keys,
+	}
+	var u string
+	if subdomain == "" {
+		u = c.url("dns/retrieveByNameType", c.Config.Domain, recordType)
+	} else {
+		u = c.url("dns/retrieveByNameType", c.Config.Domain, recordType, subdomain)
+	}
+	return doRequest[api.RecordsResponse](c, ctx, u, &req)
+}
+
+// RetrieveByID returns the record with the given ID.
+func (c *Client) RetrieveByID(ctx context.Context, id string) (*api.Record, error) {
+	if id == "" {
+		return nil, fmt.Errorf("record ID must not be empty")
+	}
+	req := api.RecordsRequest{
+		Keys: c.Config.Keys,
+	}
+	url := c.url("dns/retrieve", c.Config.Domain, id)
+	resp, err := doRequest[api.RecordsResponse](c, ctx, url, &req)
+	if err != nil {
+		return nil, err
+	}
+	switch len(resp.Records) {
+	case 0:
+		return nil, fmt.Errorf("record %s not found", id)
+	case 1:
+		return resp.Records[0], nil
+	default:
+		return nil, fmt.Errorf("expected exactly one record for ID %s, got %d", id, len(resp.Records))
+	}
+}
+
+// CreateCNAME creates a CNAME record pointing subdomain to target.
+// Porkbun stores CNAME targets without a trailing dot, so a trailing
+// dot in target is removed.
+func (c *Client) CreateCNAME(ctx context.Context, subdomain, target string) (*api.CreateResponse, error) {
+	subdomain = api.RelativeName(subdomain, c.Config.Domain)
+	if subdomain == "" {
+		return nil, fmt.Errorf("CNAME records are not allowed on the root domain, use ALIAS instead")
+	}
+	target = strings.TrimSuffix(target, ".")
+	if target == "" {
+		return nil, fmt.Errorf("CNAME target must not be empty")
+	}
+	return c.CreateRecord(ctx, api.UpdateRequest{
+		Name:    subdomain,
+		Type:    "CNAME",
+		Content: target,
+	})
+}
+
+// CreateAlias creates an ALIAS record pointing subdomain to target.
+// Unlike CNAME records, ALIAS records are allowed on the root domain.
+// target must be a hostname, not an IP address.
+func (c *Client) CreateAlias(ctx context.Context, subdomain, target string) (*api.CreateResponse, error) {
+	subdomain = api.RelativeName(subdomain, c.Config.Domain)
+	if net.ParseIP(target) != nil {
+		return nil, fmt.Errorf("ALIAS target must be a hostname, not an IP address: %s", target)
+	}
+	if !isHostname(target) {
+		return nil, fmt.Errorf("ALIAS target is not a valid hostname: %q", target)
+	}
+	return c.CreateRecord(ctx, api.UpdateRequest{
+		Name:    subdomain,
+		Type:    "ALIAS",
+		Content: strings.TrimSuffix(target, "."),
+	})
+}
+
+// CreateTXT creates a TXT record with the given content.
+//
+// The content is sent exactly as given: the client neither adds nor strips
+// quotes. Unlike in BIND zone files, Porkbun expects the unquoted record
+// text and splits long values into 255 byte strings itself.
+func (c *Client) CreateTXT(ctx context.Context, subdomain, content string) (*api.CreateResponse, error) {
+	return c.CreateRecord(ctx, api.UpdateRequest{
+		Name:    subdomain,
+		Type:    "TXT",
+		Content: content,
+	})
+}
+
+// EditTXTByName sets the content of all TXT records of subdomain.
+// As with CreateTXT, content is sent verbatim.
+func (c *Client) EditTXTByName(ctx context.Context, subdomain, content string) (*api.EditResponse, error) {
+	return c.editByNameType(ctx, "TXT", subdomain, content)
+}
+
+// isHostname returns true if s is a syntactically valid DNS hostname.
+// A single trailing dot is allowed.
+func isHostname(s string) bool {
+	s = strings.TrimSuffix(s, ".")
+	if s == "" || len(s) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(s, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func checkUint16(name string, v int) error {
+	if v < 0 || v > 65535 {
+		return fmt.Errorf("%s must be between 0 and 65535, got %d", name, v)
+	}
+	return nil
+}
+
+// CreateMX creates an MX record for subdomain with the given priority.
+func (c *Client) CreateMX(ctx context.Context, subdomain, mailHost string, priority int) (*api.CreateResponse, error) {
+	if err := checkUint16("MX priority", priority); err != nil {
+		return nil, err
+	}
+	return c.CreateRecord(ctx, api.UpdateRequest{
+		Name:    subdomain,
+		Type:    "MX",
+		Content: mailHost,
+		Prio:    strconv.Itoa(priority),
+	})
+}
+
+// CreateSRV creates an SRV record. name is the service label relative to the
+// domain, e.g. "_sip._tcp". Porkbun expects the content as "weight port target",
+// with the priority passed separately.
+func (c *Client) CreateSRV(ctx context.Context, name string, priority, weight, port int, target string) (*api.CreateResponse, error) {
+	for _, f := range []struct {
+		name string
+		v    int
+	}{{"SRV priority", priority}, {"SRV weight", weight}, {"SRV port", port}} {
+		if err := checkUint16(f.name, f.v); err != nil {
+			return nil, err
+		}
+	}
+	if target == "" {
+		return nil, fmt.Errorf("SRV target must not be empty")
+	}
+	return c.CreateRecord(ctx, api.UpdateRequest{
+		Name:    name,
+		Type:    "SRV",
+		Content: fmt.Sprintf("%d %d %s", weight, port, target),
+		Prio:    strconv.Itoa(priority),
+	})
+}
+
+// CreateCAA creates a CAA record with content `flags tag "value"`,
+// e.g. `0 issue "letsencrypt.org"`.
+func (c *Client) CreateCAA(ctx context.Context, subdomain string, flags int, tag, value string) (*api.CreateResponse, error) {
+	if flags < 0 || flags > 255 {
+		return nil, fmt.Errorf("CAA flags must be between 0 and 255, got %d", flags)
+	}
+	switch tag {
+	case "issue", "issuewild", "iodef":
+	default:
+		return nil, fmt.Errorf("invalid CAA tag %q, must be one of issue, issuewild, iodef", tag)
+	}
+	return c.CreateRecord(ctx, api.UpdateRequest{
+		Name:    subdomain,
+		Type:    "CAA",
+		Content: fmt.Sprintf("%d %s %s", flags, tag, api.QuoteCharString(value)),
+	})
+}
+
+// CreateHTTPS creates an HTTPS record with content "priority target params",
+// e.g. priority 1, target "." and params "alpn=h3,h2 port=443".
+func (c *Client) CreateHTTPS(ctx context.Context, subdomain string, priority int, target, params string) (*api.CreateResponse, error) {
+	return c.createSVCB(ctx, "HTTPS", subdomain, priority, target, params)
+}
+
+// CreateSVCB creates an SVCB record. See CreateHTTPS for the arguments.
+func (c *Client) CreateSVCB(ctx context.Context, name string, priority int, target, params string) (*api.CreateResponse, error) {
+	return c.createSVCB(ctx, "SVCB", name, priority, target, params)
+}
+
+func (c *Client) createSVCB(ctx context.Context, recordType, subdomain string, priority int, target, params string) (*api.CreateResponse, error) {
+	if err := checkUint16(recordType+" priority", priority); err != nil {
+		return nil, err
+	}
+	if target != "." && !isHostname(target) {
+		return nil, fmt.Errorf("%s target is not a valid hostname: %q", recordType, target)
+	}
+	fields := strings.Fields(params)
+	if priority == 0 && len(fields) > 0 {
+		return nil, fmt.Errorf("%s records with priority 0 (alias mode) must not have params", recordType)
+	}
+	for _, f := range fields {
+		key, value, ok := strings.Cut(f, "=")
+		if !ok || key == "" || value == "" {
+			return nil, fmt.Errorf("invalid %s param %q, must be key=value", recordType, f)
+		}
+	}
+	content := fmt.Sprintf("%d %s", priority, target)
+	if len(fields) > 0 {
+		content += " " + strings.Join(fields, " ")
+	}
+	return c.CreateRecord(ctx, api.UpdateRequest{
+		Name:    subdomain,
+		Type:    recordType,
+		Content: content,
+	})
+}
+
+// RetrieveSSL retrieves the SSL certificate bundle Porkbun issued for the domain.
+func (c *Client) RetrieveSSL(ctx context.Context) (*api.SSLBundle, error) {
+	req := api.SSLRequest{
+		Keys: c.Config.Keys,
+	}
+	return doRequest[api.SSLBundle](c, ctx, c.url("ssl/retrieve", c.Config.Domain), &req)
+}
+
+// ListDomains lists the domains in the account, starting at the given index.
+// Porkbun returns up to 1000 domains per call.
+func (c *Client) ListDomains(ctx context.Context, start int) (*api.DomainsResponse, error) {
+	req := api.DomainsRequest{
+		Keys: c.Config.Keys,
+	}
+	if start > 0 {
+		req.Start = strconv.Itoa(start)
+	}
+	return doRequest[api.DomainsResponse](c, ctx, c.url("domain/listAll"), &req)
+}
+
+// GetDomainInfo returns the account's listing of the client's domain.
+// It returns an error if the domain is not in the account.
+func (c *Client) GetDomainInfo(ctx context.Context) (*api.DomainInfo, error) {
+	for start := 0; ; start += 1000 {
+		resp, err := c.ListDomains(ctx, start)
+		if err != nil {
+			return nil, err
+		}
+		if len(resp.Domains) == 0 {
+			return nil, fmt.Errorf("domain %s not found in the account", c.Config.Domain)
+		}
+		for _, d := range resp.Domains {
+			if strings.EqualFold(d.Domain, c.Config.Domain) {
+				return d, nil
+			}
+		}
+	}
+}
+
+func (c *Client) GetNameservers(ctx context.Context) (*api.NameserversResponse, error) {
+	req := api.NameserversRequest{
+		Keys: c.Config.Keys,
+	}
+	return doRequest[api.NameserversResponse](c, ctx, c.url("domain/getNs", c.Config.Domain), &req)
+}
+
+// UpdateNameservers sets the authoritative nameservers of the domain.
+// At least two nameservers are required.
+func (c *Client) UpdateNameservers(ctx context.Context, ns []string) (*api.Status, error) {
+	if len(ns) < 2 {
+		return nil, fmt.Errorf("at least two nameservers are required, got %d", len(ns))
+	}
+	for _, n := range ns {
+		if !isHostname(n) {
+			return nil, fmt.Errorf("invalid nameserver hostname: %q", n)
+		}
+	}
+	req := api.UpdateNameserversRequest{
+		Keys: c.Config.Keys,
+		NS:   ns,
+	}
+	return doRequest[api.Status](c, ctx, c.url("domain/updateNs", c.Config.Domain), &req)
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// AddURLForward forwards subdomain to location. forwardType must be
+// "temporary" or "permanent".
+func (c *Client) AddURLForward(ctx context.Context, subdomain, location, forwardType string, includePath, wildcard bool) (*api.Status, error) {
+	if forwardType != "temporary" && forwardType != "permanent" {
+		return nil, fmt.Errorf("invalid forward type %q, must be temporary or permanent", forwardType)
+	}
+	if location == "" {
+		return nil, fmt.Errorf("forward location must not be empty")
+	}
+	req := api.URLForwardRequest{
+		Keys:        c.Config.Keys,
+		Subdomain:   subdomain,
+		Location:    location,
+		Type:        forwardType,
+		IncludePath: yesNo(includePath),
+		Wildcard:    yesNo(wildcard),
+	}
+	return doRequest[api.Status](c, ctx, c.url("domain/addUrlForward", c.Config.Domain), &req)
+}
+
+func (c *Client) GetURLForwards(ctx context.Context) (*api.URLForwardsResponse, error) {
+	req := api.URLForwardsRequest{
+		Keys: c.Config.Keys,
+	}
+	return doRequest[api.URLForwardsResponse](c, ctx, c.url("domain/getUrlForwarding", c.Config.Domain), &req)
+}
+
+func (c *Client) DeleteURLForward(ctx context.Context, id string) (*api.Status, error) {
+	if id == "" {
+		return nil, fmt.Errorf("URL forward ID must not be empty")
+	}
+	req := api.DeleteRequest{
+		Keys: c.Config.Keys,
+	}
+	return doRequest[api.Status](c, ctx, c.url("domain/deleteUrlForward", c.Config.Domain, id), &req)
+}
+
+// GetPricing returns the default domain prices for all supported TLDs.
+func (c *Client) GetPricing(ctx context.Context) (*api.PricingResponse, error) {
+	req := api.PricingRequest{}
+	return doRequest[api.PricingResponse](c, ctx, c.url("pricing/get"), &req)
+}
+
+// validateDNSSECRecord checks the DS record fields against the ranges of RFC 4034:
+// the key tag is a 16 bit value; algorithm and digest type are 8 bit values
+// where 0 and 255 are reserved; the digest is hex encoded.
+func validateDNSSECRecord(rec *api.DNSSECRecord) error {
+	keyTag, err := strconv.Atoi(rec.KeyTag)
+	if err != nil {
+		return fmt.Errorf("invalid DNSSEC key tag %q: %v", rec.KeyTag, err)
+	}
+	if err := checkUint16("DNSSEC key tag", keyTag); err != nil {
+		return err
+	}
+	for _, f := range []struct{ name, v string }{
+		{"algorithm", rec.Algorithm}, {"digest type", rec.DigestType},
+	} {
+		n, err := strconv.Atoi(f.v)
+		if err != nil || n < 1 || n > 254 {
+			return fmt.Errorf("invalid DNSSEC %s %q, must be between 1 and 254", f.name, f.v)
+		}
+	}
+	if d, err := hex.DecodeString(rec.Digest); err != nil || len(d) == 0 {
+		return fmt.Errorf("invalid DNSSEC digest %q, must be non-empty hex", rec.Digest)
+	}
+	return nil
+}
+
+// CreateDNSSECRecord publishes a DS record for the domain at the registry.
+func (c *Client) CreateDNSSECRecord(ctx context.Context, rec api.DNSSECRecord) (*api.Status, error) {
+	if err := validateDNSSECRecord(&rec); err != nil {
+		return nil, err
+	}
+	req := api.DNSSECCreateRequest{
+		Keys:         c.Config.Keys,
+		DNSSECRecord: rec,
+	}
+	return doRequest[api.Status](c, ctx, c.url("dns/createDnssecRecord", c.Config.Domain), &req)
+}
+
+func (c *Client) GetDNSSECRecords(ctx context.Context) (*api.DNSSECRecordsResponse, error) {
+	req := api.DNSSECRecordsRequest{
+		Keys: c.Config.Keys,
+	}
+	return doRequest[api.DNSSECRecordsResponse](c, ctx, c.url("dns/getDnssecRecords", c.Config.Domain), &req)
+}
+
+func (c *Client) DeleteDNSSECRecord(ctx context.Context, keyTag string) (*api.Status, error) {
+	if keyTag == "" {
+		return nil, fmt.Errorf("DNSSEC key tag must not be empty")
+	}
+	req := api.DeleteRequest{
+		Keys: c.Config.Keys,
+	}
+	return doRequest[api.Status](c, ctx, c.url("dns/deleteDnssecRecord", c.Config.Domain, keyTag), &req)
+}
+
+func glueRequest(keys api.Keys, subdomain string, ips []string) (*api.GlueRequest, error) {
+	if subdomain == "" {
+		return nil, fmt.Errorf("glue record host must not be empty")
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("glue record for %s requires at least one IP address", subdomain)
+	}
+	for _, ip := range ips {
+		if net.ParseIP(ip) == nil {
+			return nil, fmt.Errorf("invalid IP address for glue record %s: %q", subdomain, ip)
+		}
+	}
+	return &api.GlueRequest{Keys: keys, IPs: ips}, nil
+}
+
+// CreateGlueRecord creates a glue record for the host subdomain (e.g. "ns1")
+// with the given IPv4 and IPv6 addresses.
+func (c *Client) CreateGlueRecord(ctx context.Context, subdomain string, ips []string) (*api.Status, error) {
+	req, err := glueRequest(c.Config.Keys, subdomain, ips)
+	if err != nil {
+		return nil, err
+	}
+	return doRequest[api.Status](c, ctx, c.url("domain/createGlue", c.Config.Domain, subdomain), req)
+}
+
+// UpdateGlueRecord replaces the IP addresses of the glue record for subdomain.
+func (c *Client) UpdateGlueRecord(ctx context.Context, subdomain string, ips []string) (*api.Status, error) {
+	req, err := glueRequest(c.Config.Keys, subdomain, ips)
+	if err != nil {
+		return nil, err
+	}
+	return doRequest[api.Status](c, ctx, c.url("domain/updateGlue", c.Config.Domain, subdomain), req)
+}
+
+func (c *Client) GetGlueRecords(ctx context.Context) (*api.GlueRecordsResponse, error) {
+	req := api.GlueRecordsRequest{
+		Keys: c.Config.Keys,
+	}
+	return doRequest[api.GlueRecordsResponse](c, ctx, c.url("domain/getGlue", c.Config.Domain), &req)
+}
+
+func (c *Client) DeleteGlueRecord(ctx context.Context, subdomain string) (*api.Status, error) {
+	if subdomain == "" {
+		return nil, fmt.Errorf("glue record host must not be empty")
+	}
+	req := api.DeleteRequest{
+		Keys: c.Config.Keys,
+	}
+	return doRequest[api.Status](c, ctx, c.url("domain/deleteGlue", c.Config.Domain, subdomain), &req)
 }
@@ -0,0 +1,329 @@
+package porkbun_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dnswlt/porkbun/pkg/api"
+	"github.com/dnswlt/porkbun/pkg/porkbun"
+	"github.com/dnswlt/porkbun/pkg/porkbuntest"
+	"golang.org/x/time/rate"
+)
+
+// lastRequest returns the JSON body of the last call the server received.
+func lastRequest(t *testing.T, s *porkbuntest.Server) map[string]string {
+	t.Helper()
+	calls := s.Calls()
+	if len(calls) == 0 {
+		t.Fatal("server received no calls")
+	}
+	var req map[string]string
+	if err := json.Unmarshal(calls[len(calls)-1].Body, &req); err != nil {
+		t.Fatalf("invalid request body: %v", err)
+	}
+	return req
+}
+
+func TestCreateTXTContent(t *testing.T) {
+	dkim := "v=DKIM1; k=rsa; p=" + strings.Repeat("MIIBIjANBgkqhkiG9w0BAQEFAAOCAQ8A", 12)
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"long DKIM key", dkim},
+		{"pre-split", `"a" "b"`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := porkbuntest.NewServer("example.com")
+			defer s.Close()
+
+			if _, err := s.NewClient().CreateTXT(context.Background(), "mail._domainkey", tc.content); err != nil {
+				t.Fatalf("CreateTXT: %v", err)
+			}
+			req := lastRequest(t, s)
+			if req["content"] != tc.content {
+				t.Errorf("sent content %q, want %q", req["content"], tc.content)
+			}
+			if req["name"] != "mail._domainkey" || req["type"] != "TXT" {
+				t.Errorf("sent name %q, type %q", req["name"], req["type"])
+			}
+		})
+	}
+}
+
+func TestCreateCAAContent(t *testing.T) {
+	tests := []struct {
+		name       string
+		flags      int
+		tag, value string
+		want       string
+	}{
+		{"issue", 0, "issue", "letsencrypt.org", `0 issue "letsencrypt.org"`},
+		{"critical iodef", 128, "iodef", "mailto:hostmaster@example.com", `128 iodef "mailto:hostmaster@example.com"`},
+		{"escapes", 0, "issuewild", `a"b\c`, `0 issuewild "a\"b\\c"`},
+		{"UTF-8", 0, "issue", "bücher.example", `0 issue "bücher.example"`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := porkbuntest.NewServer("example.com")
+			defer s.Close()
+
+			if _, err := s.NewClient().CreateCAA(context.Background(), "", tc.flags, tc.tag, tc.value); err != nil {
+				t.Fatalf("CreateCAA(%d, %q, %q): %v", tc.flags, tc.tag, tc.value, err)
+			}
+			if got := lastRequest(t, s)["content"]; got != tc.want {
+				t.Errorf("CreateCAA(%d, %q, %q) sent content %s, want %s", tc.flags, tc.tag, tc.value, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSetNotes(t *testing.T) {
+	s := porkbuntest.NewServer("example.com")
+	defer s.Close()
+	c := s.NewClient()
+	ctx := context.Background()
+
+	created, err := c.CreateRecord(ctx, api.UpdateRequest{Name: "mail", Type: "MX", Content: "mx.example.net", TTL: "3600", Prio: "10"})
+	if err != nil {
+		t.Fatalf("CreateRecord: %v", err)
+	}
+	if _, err := c.SetNotes(ctx, created.ID, "primary mail server"); err != nil {
+		t.Fatalf("SetNotes: %v", err)
+	}
+	if req := lastRequest(t, s); req["name"] != "mail" {
+		t.Errorf("SetNotes sent name %q, want %q", req["name"], "mail")
+	}
+	r, err := c.RetrieveByID(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("RetrieveByID: %v", err)
+	}
+	want := api.Record{
+		ID:      created.ID,
+		Name:    "mail.example.com",
+		Type:    "MX",
+		Content: "mx.example.net",
+		TTL:     "3600",
+		Prio:    "10",
+		Notes:   "primary mail server",
+	}
+	if *r != want {
+		t.Errorf("after SetNotes: %+v, want %+v", *r, want)
+	}
+}
+
+func TestCreateCNAMERejectsRoot(t *testing.T) {
+	s := porkbuntest.NewServer("example.com")
+	defer s.Close()
+	c := s.NewClient()
+
+	for _, name := range []string{"", "example.com", "Example.com."} {
+		if _, err := c.CreateCNAME(context.Background(), name, "target.example.net"); err == nil {
+			t.Errorf("CreateCNAME(%q) succeeded, want error", name)
+		}
+	}
+	if n := len(s.Calls()); n != 0 {
+		t.Errorf("server received %d calls, want 0", n)
+	}
+	if _, err := c.CreateCNAME(context.Background(), "www.example.com.", "target.example.net."); err != nil {
+		t.Fatalf("CreateCNAME: %v", err)
+	}
+	req := lastRequest(t, s)
+	if req["name"] != "www" || req["content"] != "target.example.net" {
+		t.Errorf("sent name %q, content %q", req["name"], req["content"])
+	}
+}
+
+func TestStreamRecords(t *testing.T) {
+	s := porkbuntest.NewServer("example.com")
+	defer s.Close()
+	s.AddRecord(api.Record{Name: "www", Type: "A", Content: "192.0.2.1"})
+	s.AddRecord(api.Record{Type: "MX", Content: "mx.example.net", Prio: "10"})
+
+	var buf bytes.Buffer
+	n, err := s.NewClient().StreamRecords(context.Background(), &buf)
+	if err != nil {
+		t.Fatalf("StreamRecords: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("StreamRecords returned %d, want 2", n)
+	}
+	var got []*api.Record
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var r api.Record
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("invalid line %q: %v", line, err)
+		}
+		got = append(got, &r)
+	}
+	want := s.Records()
+	if len(got) != len(want) || *got[0] != *want[0] || *got[1] != *want[1] {
+		t.Errorf("StreamRecords wrote %v, want %v", got, want)
+	}
+}
+
+func TestStreamRecordsRetry(t *testing.T) {
+	const records = `{"status":"SUCCESS","records":[{"id":"1","name":"example.com","type":"A","content":"192.0.2.1"}`
+	tests := []struct {
+		name      string
+		responses []func(w http.ResponseWriter)
+		wantN     int
+		wantErr   bool
+		wantCalls int
+	}{
+		{
+			name: "retry before first record",
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) { w.WriteHeader(http.StatusServiceUnavailable) },
+				func(w http.ResponseWriter) { io.WriteString(w, records+"]}") },
+			},
+			wantN:     1,
+			wantCalls: 2,
+		},
+		{
+			name: "no retry after first record",
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) { io.WriteString(w, records+`,{"id":`) },
+				func(w http.ResponseWriter) { io.WriteString(w, records+"]}") },
+			},
+			wantN:     1,
+			wantErr:   true,
+			wantCalls: 1,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				tc.responses[calls](w)
+				calls++
+			}))
+			defer srv.Close()
+			c := porkbun.NewClient(&porkbun.ClientConfig{Domain: "example.com"},
+				porkbun.WithBaseURL(srv.URL), porkbun.WithRetry(3, time.Millisecond), porkbun.WithRateLimit(rate.Inf, 1))
+
+			n, err := c.StreamRecords(context.Background(), io.Discard)
+			if n != tc.wantN || (err != nil) != tc.wantErr {
+				t.Errorf("StreamRecords = %d, %v, want %d records and error %t", n, err, tc.wantN, tc.wantErr)
+			}
+			if calls != tc.wantCalls {
+				t.Errorf("server received %d calls, want %d", calls, tc.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		create    bool
+		wantCalls int
+	}{
+		{"retrieve 503", http.StatusServiceUnavailable, false, 3},
+		{"retrieve 429", http.StatusTooManyRequests, false, 3},
+		{"retrieve 400", http.StatusBadRequest, false, 1},
+		{"create 503", http.StatusServiceUnavailable, true, 1},
+		{"create 429", http.StatusTooManyRequests, true, 3},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tc.status)
+				io.WriteString(w, `{"status":"ERROR","message":"try again"}`)
+			}))
+			defer srv.Close()
+			c := porkbun.NewClient(&porkbun.ClientConfig{Domain: "example.com"},
+				porkbun.WithBaseURL(srv.URL), porkbun.WithRetry(3, time.Millisecond), porkbun.WithRateLimit(rate.Inf, 1))
+
+			var err error
+			if tc.create {
+				_, err = c.CreateA(context.Background(), "www", "192.0.2.1")
+			} else {
+				_, err = c.RetrieveAll(context.Background())
+			}
+			if err == nil {
+				t.Fatal("request succeeded, want error")
+			}
+			if calls != tc.wantCalls {
+				t.Errorf("server received %d calls, want %d", calls, tc.wantCalls)
+			}
+		})
+	}
+}
+
+func TestWithRetryPanics(t *testing.T) {
+	for _, tc := range []struct {
+		attempts int
+		delay    time.Duration
+	}{{0, time.Second}, {3, 0}, {3, -time.Second}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("WithRetry(%d, %v) did not panic", tc.attempts, tc.delay)
+				}
+			}()
+			porkbun.WithRetry(tc.attempts, tc.delay)
+		}()
+	}
+}
+
+func TestInvalidProxyFailsClosed(t *testing.T) {
+	s := porkbuntest.NewServer("example.com")
+	defer s.Close()
+	cfg := *s.Config
+	cfg.Proxy = "ftp://proxy.example.com"
+
+	c := porkbun.NewClient(&cfg, porkbun.WithBaseURL(s.BaseURL()), porkbun.WithRateLimit(rate.Inf, 1))
+	_, err := c.Ping(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "invalid proxy URL") {
+		t.Errorf("Ping = %v, want an invalid proxy error", err)
+	}
+	if n := len(s.Calls()); n != 0 {
+		t.Errorf("server received %d calls, want 0", n)
+	}
+}
+
+func TestPingBoth(t *testing.T) {
+	s := porkbuntest.NewServer("example.com")
+	defer s.Close()
+	s.SetYourIP("198.51.100.7")
+	var trace bytes.Buffer
+	c := s.NewClient(porkbun.WithTrace(&trace))
+
+	// The fake server only listens on IPv4, so only the IPv4 ping succeeds.
+	ips, err := c.PingBoth(context.Background())
+	if err != nil {
+		t.Fatalf("PingBoth: %v", err)
+	}
+	if ips.IPv4 != "198.51.100.7" || ips.IPv4Err != nil {
+		t.Errorf("IPv4 = %q, %v, want 198.51.100.7", ips.IPv4, ips.IPv4Err)
+	}
+	if ips.IPv6 != "" || ips.IPv6Err == nil {
+		t.Errorf("IPv6 = %q, %v, want an error", ips.IPv6, ips.IPv6Err)
+	}
+	if got := s.Endpoints(); len(got) != 1 || got[0] != "ping" {
+		t.Errorf("Endpoints = %v, want [ping]", got)
+	}
+	if n := strings.Count(trace.String(), "> POST "+s.BaseURL()+"ping"); n != 2 {
+		t.Errorf("trace has %d ping requests, want 2:\n%s", n, trace.String())
+	}
+
+	// An IPv6 address reported over IPv4 is rejected.
+	s.SetYourIP("2001:db8::1")
+	if _, err := c.PingBoth(context.Background()); err == nil {
+		t.Error("PingBoth with an IPv6 address over IPv4 succeeded")
+	}
+}
@@ -0,0 +1,122 @@
+package porkbun
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DefaultPollInterval is the initial interval between propagation checks.
+const DefaultPollInterval = 2 * time.Second
+
+// maxPollInterval caps the exponential backoff of propagation checks.
+const maxPollInterval = time.Minute
+
+// A PropagationOption configures WaitForPropagation and WaitForTXT.
+type PropagationOption func(*propagationConfig)
+
+type propagationConfig struct {
+	interval    time.Duration
+	nameservers []string
+}
+
+// WithPollInterval sets the initial interval between propagation checks.
+// The interval doubles after each unsuccessful check, up to one minute.
+// Non-positive values select DefaultPollInterval.
+func WithPollInterval(d time.Duration) PropagationOption {
+	if d <= 0 {
+		d = DefaultPollInterval
+	}
+	return func(pc *propagationConfig) {
+		pc.interval = d
+	}
+}
+
+// WithNameservers makes propagation checks query the given nameservers
+// ("host" or "host:port") instead of the authoritative nameservers of the domain.
+func WithNameservers(nameservers ...string) PropagationOption {
+	return func(pc *propagationConfig) {
+		pc.nameservers = nameservers
+	}
+}
+
+// WaitForPropagation waits until all nameservers return expectedIP as an A record
+// (for IPv4 addresses) or AAAA record (for IPv6 addresses) of fqdn, or ctx is done.
+// By default, it queries the authoritative nameservers of the client's domain.
+func (c *Client) WaitForPropagation(ctx context.Context, fqdn, expectedIP string, opts ...PropagationOption) error {
+	ip := net.ParseIP(expectedIP)
+	if ip == nil {
+		return fmt.Errorf("invalid IP address %q", expectedIP)
+	}
+	network := "ip6"
+	if ip.To4() != nil {
+		network = "ip4"
+	}
+	return c.waitFor(ctx, fqdn, opts, func(r *net.Resolver) bool {
+		ips, err := r.LookupIP(ctx, network, fqdn)
+		return err == nil && slices.ContainsFunc(ips, ip.Equal)
+	})
+}
+
+// WaitForTXT waits until all nameservers return value as a TXT record of fqdn,
+// or ctx is done. See WaitForPropagation for the nameservers queried.
+func (c *Client) WaitForTXT(ctx context.Context, fqdn, value string, opts ...PropagationOption) error {
+	return c.waitFor(ctx, fqdn, opts, func(r *net.Resolver) bool {
+		txts, err := r.LookupTXT(ctx, fqdn)
+		return err == nil && slices.Contains(txts, value)
+	})
+}
+
+// waitFor polls the configured nameservers with exponential backoff
+// until matches returns true for each of them.
+func (c *Client) waitFor(ctx context.Context, fqdn string, opts []PropagationOption, matches func(*net.Resolver) bool) error {
+	pc := &propagationConfig{interval: DefaultPollInterval}
+	for _, opt := range opts {
+		opt(pc)
+	}
+	pending := pc.nameservers
+	if len(pending) == 0 {
+		nss, err := net.DefaultResolver.LookupNS(ctx, c.Config.Domain)
+		if err != nil {
+			return fmt.Errorf("failed to look up nameservers: %w", err)
+		}
+		for _, ns := range nss {
+			pending = append(pending, ns.Host)
+		}
+	}
+	pending = slices.Clone(pending)
+	interval := pc.interval
+	for {
+		pending = slices.DeleteFunc(pending, func(ns string) bool {
+			return matches(nsResolver(ns))
+		})
+		if len(pending) == 0 {
+			return nil
+		}
+		c.logger.Debugf("%s has not propagated to %s yet, retrying in %v", fqdn, strings.Join(pending, ", "), interval)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s did not propagate to %s: %w", fqdn, strings.Join(pending, ", "), ctx.Err())
+		case <-time.After(interval):
+		}
+		interval = min(2*interval, maxPollInterval)
+	}
+}
+
+// nsResolver returns a resolver that sends all queries to nameserver ns.
+func nsResolver(ns string) *net.Resolver {
+	addr := ns
+	if _, _, err := net.SplitHostPort(ns); err != nil {
+		addr = net.JoinHostPort(ns, "53")
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		},
+	}
+}
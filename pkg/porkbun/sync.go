@@ -0,0 +1,215 @@
+package porkbun
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/dnswlt/porkbun/pkg/api"
+)
+
+// ChangeKind is the kind of a Change in a sync Plan.
+type ChangeKind string
+
+const (
+	ChangeCreate ChangeKind = "create"
+	ChangeUpdate ChangeKind = "update"
+	ChangeDelete ChangeKind = "delete"
+)
+
+// Change is a single create, update, or delete call of a sync Plan.
+type Change struct {
+	Kind ChangeKind
+	// Name is the fully qualified name of the record.
+	Name string
+	// Desired is the desired state of the record. Nil for deletions.
+	Desired *api.UpdateRequest
+	// Existing is the current record. Nil for creations.
+	Existing *api.Record
+}
+
+func (c *Change) String() string {
+	switch c.Kind {
+	case ChangeCreate:
+		return fmt.Sprintf("+ create %s %s %s", c.Name, c.Desired.Type, c.Desired.Content)
+	case ChangeUpdate:
+		return fmt.Sprintf("~ update %s %s %s -> %s", c.Name, c.Existing.Type, c.Existing.Content, c.Desired.Content)
+	case ChangeDelete:
+		return fmt.Sprintf("- delete %s %s %s", c.Name, c.Existing.Type, c.Existing.Content)
+	}
+	return fmt.Sprintf("? %s", c.Kind)
+}
+
+// Plan is the list of changes that Sync applies to reach the desired state.
+type Plan []*Change
+
+// syncKey identifies the records that Sync matches against each other:
+// records with the same name and type, and, for MX records, the same priority.
+type syncKey struct {
+	name, typ, prio string
+}
+
+// DecodeDesired decodes the desired records for PlanSync from a list of objects
+// with the fields of api.UpdateRequest, in the format given by the file extension
+// ext, as for config files: YAML for ".yaml" and ".yml", and JSON otherwise.
+// TTL and priority may be given as numbers or strings.
+func DecodeDesired(data []byte, ext string) ([]api.UpdateRequest, error) {
+	var desired []api.UpdateRequest
+	if err := unmarshalByExt(data, ext, &desired); err != nil {
+		return nil, err
+	}
+	return desired, nil
+}
+
+// fqdn returns the fully qualified name of subdomain, which may be absolute already.
+func (c *Client) fqdn(subdomain string) string {
+	subdomain = api.RelativeName(subdomain, c.Config.Domain)
+	if subdomain == "" {
+		return c.Config.Domain
+	}
+	return subdomain + "." + c.Config.Domain
+}
+
+// PlanSync computes the changes needed to turn the current records of the domain
+// into the desired records. Names in desired are subdomains relative to the domain.
+//
+// Records are matched by name and type (and priority, for MX records).
+// For each such name and type in desired, records are created, updated, and deleted
+// so that exactly the desired records exist. If prune is true, records whose
+// name and type do not occur in desired are deleted as well, except for
+// the NS records of the root domain.
+//
+// The plan is stable-sorted by name, then type, so that plans are comparable across runs.
+func (c *Client) PlanSync(ctx context.Context, desired []api.UpdateRequest, prune bool) (Plan, error) {
+	resp, err := c.RetrieveAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keyOf := func(name, typ, prio string) syncKey {
+		// Porkbun may report names in a different case than desired.
+		k := syncKey{name: strings.ToLower(strings.TrimSuffix(name, ".")), typ: typ}
+		if typ == "MX" {
+			k.prio = prio
+		}
+		return k
+	}
+	existing := make(map[syncKey][]*api.Record)
+	var keys []syncKey
+	for _, r := range resp.Records {
+		k := keyOf(r.Name, r.Type, r.Prio)
+		if existing[k] == nil {
+			keys = append(keys, k)
+		}
+		existing[k] = append(existing[k], r)
+	}
+	wanted := make(map[syncKey][]*api.UpdateRequest)
+	var wantedKeys []syncKey
+	for i := range desired {
+		d := &desired[i]
+		if err := validateRecordType(d.Type); err != nil {
+			return nil, err
+		}
+		k := keyOf(c.fqdn(d.Name), d.Type, d.Prio)
+		if wanted[k] == nil {
+			wantedKeys = append(wantedKeys, k)
+		}
+		wanted[k] = append(wanted[k], d)
+	}
+
+	var plan Plan
+	for _, k := range wantedKeys {
+		ds, rs := wanted[k], existing[k]
+		// Records that already have the desired content need no change.
+		var unmatched []*api.UpdateRequest
+		for _, d := range ds {
+			i := -1
+			for j, r := range rs {
+				// Porkbun stores targets without a trailing dot, see MatchesDesired.
+				if strings.TrimSuffix(r.Content, ".") == strings.TrimSuffix(d.Content, ".") {
+					i = j
+					break
+				}
+			}
+			if i < 0 {
+				unmatched = append(unmatched, d)
+				continue
+			}
+			if !rs[i].MatchesDesired(d, c.Config.Domain) {
+				plan = append(plan, &Change{Kind: ChangeUpdate, Name: k.name, Desired: d, Existing: rs[i]})
+			}
+			rs = append(rs[:i:i], rs[i+1:]...)
+		}
+		// Edit the remaining existing records, then create or delete the rest.
+		for _, d := range unmatched {
+			if len(rs) > 0 {
+				plan = append(plan, &Change{Kind: ChangeUpdate, Name: k.name, Desired: d, Existing: rs[0]})
+				rs = rs[1:]
+			} else {
+				plan = append(plan, &Change{Kind: ChangeCreate, Name: k.name, Desired: d})
+			}
+		}
+		for _, r := range rs {
+			plan = append(plan, &Change{Kind: ChangeDelete, Name: k.name, Existing: r})
+		}
+	}
+	if prune {
+		for _, k := range keys {
+			if _, ok := wanted[k]; ok {
+				continue
+			}
+			if k.typ == "NS" && k.name == keyOf(c.Config.Domain, "", "").name {
+				continue
+			}
+			for _, r := range existing[k] {
+				plan = append(plan, &Change{Kind: ChangeDelete, Name: k.name, Existing: r})
+			}
+		}
+	}
+	slices.SortStableFunc(plan, func(a, b *Change) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.recordType(), b.recordType()))
+	})
+	return plan, nil
+}
+
+func (c *Change) recordType() string {
+	if c.Desired != nil {
+		return c.Desired.Type
+	}
+	return c.Existing.Type
+}
+
+// ApplyPlan executes the changes of plan. It continues after failed changes
+// and returns all errors.
+func (c *Client) ApplyPlan(ctx context.Context, plan Plan) error {
+	var errs []error
+	for _, ch := range plan {
+		var err error
+		switch ch.Kind {
+		case ChangeCreate:
+			_, err = c.CreateRecord(ctx, *ch.Desired)
+		case ChangeUpdate:
+			_, err = c.EditRecord(ctx, ch.Existing.ID, *ch.Desired)
+		case ChangeDelete:
+			_, err = c.DeleteRecord(ctx, ch.Existing.ID)
+		default:
+			err = fmt.Errorf("unknown change kind %q", ch.Kind)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", ch, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Sync reconciles the records of the domain with desired, as described in PlanSync,
+// and returns the applied plan.
+func (c *Client) Sync(ctx context.Context, desired []api.UpdateRequest, prune bool) (Plan, error) {
+	plan, err := c.PlanSync(ctx, desired, prune)
+	if err != nil {
+		return nil, err
+	}
+	return plan, c.ApplyPlan(ctx, plan)
+}
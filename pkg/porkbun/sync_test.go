@@ -0,0 +1,85 @@
+package porkbun_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dnswlt/porkbun/pkg/api"
+	"github.com/dnswlt/porkbun/pkg/porkbun"
+	"github.com/dnswlt/porkbun/pkg/porkbuntest"
+)
+
+func TestPlanSyncIgnoresNameCase(t *testing.T) {
+	s := porkbuntest.NewServer("example.com")
+	defer s.Close()
+	s.AddRecord(api.Record{Name: "WWW", Type: "A", Content: "192.0.2.1"})
+	s.AddRecord(api.Record{Name: "Mail", Type: "A", Content: "192.0.2.2"})
+
+	desired := []api.UpdateRequest{
+		{Name: "www", Type: "A", Content: "192.0.2.1"},
+		{Name: "mail.example.com.", Type: "A", Content: "192.0.2.3"},
+	}
+	plan, err := s.NewClient().PlanSync(context.Background(), desired, true)
+	if err != nil {
+		t.Fatalf("PlanSync: %v", err)
+	}
+	if len(plan) != 1 || plan[0].Kind != porkbun.ChangeUpdate || plan[0].Existing.Content != "192.0.2.2" {
+		t.Errorf("PlanSync = %v, want a single update of mail", plan)
+	}
+}
+
+func TestPlanSyncIgnoresTrailingDot(t *testing.T) {
+	s := porkbuntest.NewServer("example.com")
+	defer s.Close()
+	s.AddRecord(api.Record{Name: "www", Type: "CNAME", Content: "target.example.net"})
+	s.AddRecord(api.Record{Type: "MX", Content: "mx.example.net", Prio: "10"})
+
+	desired := []api.UpdateRequest{
+		{Name: "www", Type: "CNAME", Content: "target.example.net."},
+		{Type: "MX", Content: "mx.example.net.", Prio: "10"},
+	}
+	plan, err := s.NewClient().PlanSync(context.Background(), desired, true)
+	if err != nil {
+		t.Fatalf("PlanSync: %v", err)
+	}
+	if len(plan) != 0 {
+		t.Errorf("PlanSync = %v, want no changes", plan)
+	}
+}
+
+func TestDecodeDesired(t *testing.T) {
+	want := []api.UpdateRequest{
+		{Name: "www", Type: "A", Content: "192.0.2.1", TTL: "600"},
+		{Type: "MX", Content: "mx.example.net", Prio: "10"},
+	}
+	inputs := map[string]string{
+		".json": `[{"name": "www", "type": "A", "content": "192.0.2.1", "ttl": "600"},
+			{"type": "MX", "content": "mx.example.net", "prio": "10"}]`,
+		".yaml": `
+- name: www
+  type: A
+  content: 192.0.2.1
+  ttl: "600"
+- type: MX
+  content: mx.example.net
+  prio: "10"
+`,
+	}
+	inputs[".yml"] = `
+- {name: www, type: A, content: 192.0.2.1, ttl: 600}
+- {type: MX, content: mx.example.net, prio: 10}
+`
+	inputs[".json numbers"] = `[{"name": "www", "type": "A", "content": "192.0.2.1", "ttl": 600},
+		{"type": "MX", "content": "mx.example.net", "prio": 10}]`
+	for ext, data := range inputs {
+		got, err := porkbun.DecodeDesired([]byte(data), strings.Fields(ext)[0])
+		if err != nil {
+			t.Errorf("DecodeDesired(%s): %v", ext, err)
+			continue
+		}
+		if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+			t.Errorf("DecodeDesired(%s) = %+v, want %+v", ext, got, want)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+// Package porkbunacme implements an ACME DNS-01 challenge provider for Porkbun
+// that is compatible with the challenge.Provider interface of go-acme/lego.
+package porkbunacme
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dnswlt/porkbun/pkg/porkbun"
+)
+
+// Default values for Provider timeouts.
+const (
+	DefaultPropagationTimeout = 5 * time.Minute
+	DefaultPollingInterval    = 10 * time.Second
+)
+
+// Provider solves DNS-01 challenges by creating _acme-challenge TXT records
+// in the client's domain.
+type Provider struct {
+	Client *porkbun.Client
+	// PropagationTimeout is the maximum time Present waits for the TXT record
+	// to appear on all authoritative nameservers.
+	PropagationTimeout time.Duration
+	// PollingInterval is the initial time between propagation checks.
+	PollingInterval time.Duration
+}
+
+// NewProvider returns a provider with default timeouts.
+func NewProvider(client *porkbun.Client) *Provider {
+	return &Provider{
+		Client:             client,
+		PropagationTimeout: DefaultPropagationTimeout,
+		PollingInterval:    DefaultPollingInterval,
+	}
+}
+
+// ChallengeValue returns the TXT record value for keyAuth, as defined by RFC 8555.
+func ChallengeValue(keyAuth string) string {
+	h := sha256.Sum256([]byte(keyAuth))
+	return base64.RawURLEncoding.EncodeToString(h[:])
+}
+
+// challengeSubdomain returns the subdomain of the challenge record for domain,
+// relative to the client's domain.
+func (p *Provider) challengeSubdomain(domain string) (string, error) {
+	zone := strings.ToLower(strings.TrimSuffix(p.Client.Config.Domain, "."))
+	domain = strings.TrimPrefix(strings.TrimSuffix(strings.ToLower(domain), "."), "*.")
+	if domain == zone {
+		return "_acme-challenge", nil
+	}
+	sub, ok := strings.CutSuffix(domain, "."+zone)
+	if !ok {
+		return "", fmt.Errorf("domain %s is not in zone %s", domain, zone)
+	}
+	return "_acme-challenge." + sub, nil
+}
+
+// Present creates the TXT record for the challenge and waits until
+// all authoritative nameservers of the domain serve it.
+func (p *Provider) Present(domain, token, keyAuth string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), p.PropagationTimeout)
+	defer cancel()
+	value := ChallengeValue(keyAuth)
+	if err := p.CreateRecord(ctx, domain, value); err != nil {
+		return err
+	}
+	return p.WaitForRecord(ctx, domain, value)
+}
+
+// CleanUp deletes all TXT records of the challenge subdomain.
+func (p *Provider) CleanUp(domain, token, keyAuth string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), p.PropagationTimeout)
+	defer cancel()
+	return p.DeleteRecords(ctx, domain)
+}
+
+// CreateRecord creates the _acme-challenge TXT record for domain with the given value.
+func (p *Provider) CreateRecord(ctx context.Context, domain, value string) error {
+	sub, err := p.challengeSubdomain(domain)
+	if err != nil {
+		return err
+	}
+	if _, err := p.Client.CreateTXT(ctx, sub, value); err != nil {
+		return fmt.Errorf("failed to create TXT record: %w", err)
+	}
+	return nil
+}
+
+// DeleteRecords deletes all _acme-challenge TXT records for domain.
+func (p *Provider) DeleteRecords(ctx context.Context, domain string) error {
+	sub, err := p.challengeSubdomain(domain)
+	if err != nil {
+		return err
+	}
+	if _, err := p.Client.DeleteByNameType(ctx, "TXT", sub); err != nil {
+		return fmt.Errorf("failed to delete TXT records: %w", err)
+	}
+	return nil
+}
+
+// WaitForRecord polls the authoritative nameservers until all of them serve
+// the _acme-challenge TXT record for domain with the given value, or ctx is done.
+func (p *Provider) WaitForRecord(ctx context.Context, domain, value string) error {
+	sub, err := p.challengeSubdomain(domain)
+	if err != nil {
+		return err
+	}
+	return p.Client.WaitForTXT(ctx, sub+"."+p.Client.Config.Domain, value,
+		porkbun.WithPollInterval(p.PollingInterval))
+}
+
+// Timeout returns the propagation timeout and polling interval,
+// implementing lego's challenge.ProviderTimeout interface.
+func (p *Provider) Timeout() (timeout, interval time.Duration) {
+	return p.PropagationTimeout, p.PollingInterval
+}
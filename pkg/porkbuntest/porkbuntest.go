@@ -0,0 +1,320 @@
+// Package porkbuntest provides an in-memory fake of the Porkbun API for tests.
+package porkbuntest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/dnswlt/porkbun/pkg/api"
+	"github.com/dnswlt/porkbun/pkg/porkbun"
+	"golang.org/x/time/rate"
+)
+
+const apiPrefix = "/api/json/v3/"
+
+// Call is a request received by a Server.
+type Call struct {
+	// Endpoint is the request path relative to the API base URL,
+	// e.g. "dns/create/example.com".
+	Endpoint string
+	// Body is the raw JSON request body.
+	Body []byte
+}
+
+// Server is a fake Porkbun API server for a single domain, backed by an
+// in-memory record store. It implements the ping, dns/retrieve,
+// dns/retrieveByNameType, dns/create, dns/edit, dns/editByNameType,
+// dns/delete, and dns/deleteByNameType endpoints.
+type Server struct {
+	*httptest.Server
+
+	// Config holds the domain and the keys that the server accepts.
+	Config *porkbun.ClientConfig
+
+	mu      sync.Mutex
+	yourIP  string
+	records []*api.Record
+	nextID  int
+	calls   []Call
+}
+
+// NewServer starts a fake Porkbun API server for domain. Callers must Close it.
+func NewServer(domain string) *Server {
+	s := &Server{
+		Config: &porkbun.ClientConfig{
+			Domain: domain,
+			Keys: api.Keys{
+				APIKey:       "pk1_test",
+				SecretAPIKey: "sk1_test",
+			},
+		},
+		yourIP: "192.0.2.1",
+		nextID: 1000,
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// BaseURL returns the base URL to pass to porkbun.WithBaseURL.
+func (s *Server) BaseURL() string {
+	return s.URL + apiPrefix
+}
+
+// NewClient returns a client that talks to the server, without rate limiting.
+// Further options are applied after the server's own options.
+func (s *Server) NewClient(opts ...porkbun.Option) *porkbun.Client {
+	opts = append([]porkbun.Option{
+		porkbun.WithBaseURL(s.BaseURL()),
+		porkbun.WithHTTPClient(s.Client()),
+		porkbun.WithRateLimit(rate.Inf, 1),
+	}, opts...)
+	return porkbun.NewClient(s.Config, opts...)
+}
+
+// SetYourIP sets the IP address reported by ping.
+func (s *Server) SetYourIP(ip string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.yourIP = ip
+}
+
+// AddRecord seeds the store with a copy of r and returns its ID.
+// Name is the relative subdomain, as in api.UpdateRequest.
+// Empty ID, TTL, and Prio fields are filled with defaults.
+func (s *Server) AddRecord(r api.Record) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addRecord(&r)
+}
+
+// Records returns a copy of all records in the store.
+func (s *Server) Records() []*api.Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rs := make([]*api.Record, len(s.records))
+	for i, r := range s.records {
+		c := *r
+		rs[i] = &c
+	}
+	return rs
+}
+
+// Calls returns all calls received so far, in order.
+func (s *Server) Calls() []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.calls)
+}
+
+// Endpoints returns the endpoints of all calls received so far, in order.
+func (s *Server) Endpoints() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	es := make([]string, len(s.calls))
+	for i, c := range s.calls {
+		es[i] = c.Endpoint
+	}
+	return es
+}
+
+// fqdn returns the name Porkbun reports for subdomain.
+func (s *Server) fqdn(subdomain string) string {
+	if subdomain == "" {
+		return s.Config.Domain
+	}
+	return subdomain + "." + s.Config.Domain
+}
+
+func (s *Server) addRecord(r *api.Record) string {
+	if r.ID == "" {
+		r.ID = strconv.Itoa(s.nextID)
+		s.nextID++
+	}
+	r.Name = s.fqdn(r.Name)
+	if r.TTL == "" {
+		r.TTL = "600"
+	}
+	if r.Prio == "" {
+		r.Prio = "0"
+	}
+	s.records = append(s.records, r)
+	return r.ID
+}
+
+func (s *Server) matching(typ, subdomain string) []*api.Record {
+	name := s.fqdn(subdomain)
+	var rs []*api.Record
+	for _, r := range s.records {
+		if r.Type == typ && r.Name == name {
+			rs = append(rs, r)
+		}
+	}
+	return rs
+}
+
+type request struct {
+	api.Keys
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Content string `json:"content"`
+	TTL     string `json:"ttl"`
+	Prio    string `json:"prio"`
+	Notes   string `json:"notes"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, format string, args ...any) {
+	writeJSON(w, status, api.Status{Status: api.StatusError, Message: fmt.Sprintf(format, args...)})
+}
+
+var success = api.Status{Status: api.StatusSuccess}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || !strings.HasPrefix(r.URL.Path, apiPrefix) {
+		http.NotFound(w, r)
+		return
+	}
+	endpoint := strings.TrimPrefix(r.URL.Path, apiPrefix)
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "cannot read body: %v", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, Call{Endpoint: endpoint, Body: body})
+
+	var req request
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: %v", err)
+		return
+	}
+	if req.Keys != s.Config.Keys {
+		writeError(w, http.StatusBadRequest, "Invalid API key. (002)")
+		return
+	}
+
+	parts := strings.Split(endpoint, "/")
+	if parts[0] == "ping" && len(parts) == 1 {
+		writeJSON(w, http.StatusOK, api.PingResponse{Status: success, YourIP: s.yourIP})
+		return
+	}
+	if parts[0] != "dns" || len(parts) < 3 {
+		writeError(w, http.StatusNotFound, "unsupported endpoint: %s", endpoint)
+		return
+	}
+	op, domain, args := parts[1], parts[2], parts[3:]
+	if domain != s.Config.Domain {
+		writeError(w, http.StatusBadRequest, "Invalid domain.")
+		return
+	}
+	// Optional trailing subdomain argument of the ...ByNameType endpoints.
+	typ, subdomain := "", ""
+	if len(args) > 0 {
+		typ = args[0]
+	}
+	if len(args) > 1 {
+		subdomain = args[1]
+	}
+
+	switch {
+	case op == "retrieve" && len(args) == 0:
+		writeJSON(w, http.StatusOK, api.RecordsResponse{Status: success, Records: s.records})
+
+	case op == "retrieve" && len(args) == 1:
+		i := slices.IndexFunc(s.records, func(r *api.Record) bool { return r.ID == args[0] })
+		records := []*api.Record{}
+		if i >= 0 {
+			records = append(records, s.records[i])
+		}
+		writeJSON(w, http.StatusOK, api.RecordsResponse{Status: success, Records: records})
+
+	case op == "retrieveByNameType" && (len(args) == 1 || len(args) == 2):
+		records := s.matching(typ, subdomain)
+		if records == nil {
+			records = []*api.Record{}
+		}
+		writeJSON(w, http.StatusOK, api.RecordsResponse{Status: success, Records: records})
+
+	case op == "create" && len(args) == 0:
+		if !api.ValidRecordType(req.Type) || req.Content == "" {
+			writeError(w, http.StatusBadRequest, "Invalid record.")
+			return
+		}
+		id := s.addRecord(&api.Record{
+			Name:    req.Name,
+			Type:    req.Type,
+			Content: req.Content,
+			TTL:     req.TTL,
+			Prio:    req.Prio,
+			Notes:   req.Notes,
+		})
+		writeJSON(w, http.StatusOK, api.CreateResponse{Status: success, ID: id})
+
+	case op == "edit" && len(args) == 1:
+		i := slices.IndexFunc(s.records, func(r *api.Record) bool { return r.ID == args[0] })
+		if i < 0 {
+			writeError(w, http.StatusBadRequest, "Invalid record ID.")
+			return
+		}
+		rec := s.records[i]
+		rec.Name = s.fqdn(req.Name)
+		rec.Type = req.Type
+		rec.Content = req.Content
+		if req.TTL != "" {
+			rec.TTL = req.TTL
+		}
+		if req.Prio != "" {
+			rec.Prio = req.Prio
+		}
+		if req.Notes != "" {
+			rec.Notes = req.Notes
+		}
+		writeJSON(w, http.StatusOK, success)
+
+	case op == "editByNameType" && (len(args) == 1 || len(args) == 2):
+		for _, rec := range s.matching(typ, subdomain) {
+			rec.Content = req.Content
+			if req.TTL != "" {
+				rec.TTL = req.TTL
+			}
+			if req.Prio != "" {
+				rec.Prio = req.Prio
+			}
+			if req.Notes != "" {
+				rec.Notes = req.Notes
+			}
+		}
+		writeJSON(w, http.StatusOK, success)
+
+	case op == "delete" && len(args) == 1:
+		n := len(s.records)
+		s.records = slices.DeleteFunc(s.records, func(r *api.Record) bool { return r.ID == args[0] })
+		if len(s.records) == n {
+			writeError(w, http.StatusBadRequest, "Invalid record ID.")
+			return
+		}
+		writeJSON(w, http.StatusOK, success)
+
+	case op == "deleteByNameType" && (len(args) == 1 || len(args) == 2):
+		name := s.fqdn(subdomain)
+		s.records = slices.DeleteFunc(s.records, func(r *api.Record) bool { return r.Type == typ && r.Name == name })
+		writeJSON(w, http.StatusOK, success)
+
+	default:
+		writeError(w, http.StatusNotFound, "unsupported endpoint: %s", endpoint)
+	}
+}
@@ -0,0 +1,152 @@
+package porkbuntest_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/dnswlt/porkbun/pkg/api"
+	"github.com/dnswlt/porkbun/pkg/porkbun"
+	"github.com/dnswlt/porkbun/pkg/porkbuntest"
+)
+
+func TestPing(t *testing.T) {
+	s := porkbuntest.NewServer("example.com")
+	defer s.Close()
+	s.SetYourIP("198.51.100.7")
+
+	resp, err := s.NewClient().Ping(context.Background())
+	if err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+	if resp.YourIP != "198.51.100.7" {
+		t.Errorf("YourIP = %q, want %q", resp.YourIP, "198.51.100.7")
+	}
+}
+
+func TestInvalidKeys(t *testing.T) {
+	s := porkbuntest.NewServer("example.com")
+	defer s.Close()
+	cfg := *s.Config
+	cfg.SecretAPIKey = "sk1_other"
+
+	c := porkbun.NewClient(&cfg, porkbun.WithBaseURL(s.BaseURL()), porkbun.WithHTTPClient(s.Client()))
+	if _, err := c.Ping(context.Background()); err == nil {
+		t.Error("Ping with invalid keys succeeded")
+	}
+}
+
+func TestCreateRetrieve(t *testing.T) {
+	s := porkbuntest.NewServer("example.com")
+	defer s.Close()
+	c := s.NewClient()
+	ctx := context.Background()
+
+	created, err := c.CreateRecord(ctx, api.UpdateRequest{Name: "www", Type: "A", Content: "192.0.2.10"})
+	if err != nil {
+		t.Fatalf("CreateRecord: %v", err)
+	}
+	r, err := c.RetrieveByID(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("RetrieveByID: %v", err)
+	}
+	want := api.Record{ID: created.ID, Name: "www.example.com", Type: "A", Content: "192.0.2.10", TTL: "600", Prio: "0"}
+	if *r != want {
+		t.Errorf("RetrieveByID = %+v, want %+v", *r, want)
+	}
+
+	all, err := c.RetrieveAll(ctx)
+	if err != nil {
+		t.Fatalf("RetrieveAll: %v", err)
+	}
+	if len(all.Records) != 1 || *all.Records[0] != want {
+		t.Errorf("RetrieveAll = %v, want [%v]", all.Records, &want)
+	}
+
+	byName, err := c.RetrieveByNameType(ctx, "A", "www")
+	if err != nil {
+		t.Fatalf("RetrieveByNameType: %v", err)
+	}
+	if len(byName.Records) != 1 {
+		t.Errorf("RetrieveByNameType returned %d records, want 1", len(byName.Records))
+	}
+	none, err := c.RetrieveByNameType(ctx, "AAAA", "www")
+	if err != nil {
+		t.Fatalf("RetrieveByNameType: %v", err)
+	}
+	if len(none.Records) != 0 {
+		t.Errorf("RetrieveByNameType(AAAA) returned %d records, want 0", len(none.Records))
+	}
+
+	if _, err := c.CreateRecord(ctx, api.UpdateRequest{Name: "www", Type: "A"}); err == nil {
+		t.Error("CreateRecord without content succeeded")
+	}
+}
+
+func TestEdit(t *testing.T) {
+	s := porkbuntest.NewServer("example.com")
+	defer s.Close()
+	c := s.NewClient()
+	ctx := context.Background()
+	id := s.AddRecord(api.Record{Name: "www", Type: "A", Content: "192.0.2.1", TTL: "3600", Notes: "web"})
+	s.AddRecord(api.Record{Name: "www", Type: "A", Content: "192.0.2.2"})
+
+	if _, err := c.EditRecord(ctx, id, api.UpdateRequest{Name: "www", Type: "A", Content: "192.0.2.3"}); err != nil {
+		t.Fatalf("EditRecord: %v", err)
+	}
+	r, err := c.RetrieveByID(ctx, id)
+	if err != nil {
+		t.Fatalf("RetrieveByID: %v", err)
+	}
+	// Empty TTL and notes keep the current values.
+	if r.Content != "192.0.2.3" || r.TTL != "3600" || r.Notes != "web" {
+		t.Errorf("after EditRecord: %+v", *r)
+	}
+
+	if _, err := c.EditAllA(ctx, "www", "192.0.2.4"); err != nil {
+		t.Fatalf("EditAllA: %v", err)
+	}
+	for _, r := range s.Records() {
+		if r.Content != "192.0.2.4" {
+			t.Errorf("after EditAllA: record %s has content %q", r.ID, r.Content)
+		}
+	}
+
+	if _, err := c.EditRecord(ctx, "42", api.UpdateRequest{Type: "A", Content: "192.0.2.5"}); err == nil {
+		t.Error("EditRecord of an unknown ID succeeded")
+	}
+}
+
+func TestDelete(t *testing.T) {
+	s := porkbuntest.NewServer("example.com")
+	defer s.Close()
+	c := s.NewClient()
+	ctx := context.Background()
+	id := s.AddRecord(api.Record{Name: "www", Type: "A", Content: "192.0.2.1"})
+	s.AddRecord(api.Record{Name: "_acme-challenge", Type: "TXT", Content: "a"})
+	s.AddRecord(api.Record{Name: "_acme-challenge", Type: "TXT", Content: "b"})
+	keep := s.AddRecord(api.Record{Type: "TXT", Content: "v=spf1 -all"})
+
+	if _, err := c.DeleteRecord(ctx, id); err != nil {
+		t.Fatalf("DeleteRecord: %v", err)
+	}
+	if _, err := c.DeleteRecord(ctx, id); err == nil {
+		t.Error("second DeleteRecord succeeded")
+	}
+	if _, err := c.DeleteByNameType(ctx, "TXT", "_acme-challenge"); err != nil {
+		t.Fatalf("DeleteByNameType: %v", err)
+	}
+	rs := s.Records()
+	if len(rs) != 1 || rs[0].ID != keep {
+		t.Errorf("remaining records = %v, want only %s", rs, keep)
+	}
+
+	want := []string{
+		"dns/delete/example.com/" + id,
+		"dns/delete/example.com/" + id,
+		"dns/deleteByNameType/example.com/TXT/_acme-challenge",
+	}
+	if got := s.Endpoints(); !slices.Equal(got, want) {
+		t.Errorf("Endpoints = %v, want %v", got, want)
+	}
+}
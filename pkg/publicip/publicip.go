@@ -0,0 +1,135 @@
+// Package publicip determines the public IP address of the host.
+package publicip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/dnswlt/porkbun/pkg/porkbun"
+)
+
+// Well-known URLs that return the caller's public IP address as plain text.
+const (
+	IpifyV4URL       = "https://api.ipify.org"
+	IpifyV6URL       = "https://api6.ipify.org"
+	IfconfigMeURL    = "https://ifconfig.me/ip"
+	GoogleSTUNServer = "stun.l.google.com:19302"
+)
+
+// An IPResolver determines the public IP address of the host.
+type IPResolver interface {
+	// PublicIP returns the public IP address of the host.
+	PublicIP(ctx context.Context) (net.IP, error)
+	// Name returns a short name of the resolver, used in logs and errors.
+	Name() string
+}
+
+// PorkbunResolver uses the Porkbun ping endpoint, which requires valid API keys.
+// Which address family is reported depends on the client's base URL and
+// dial family, see porkbun.WithIPv4 and porkbun.WithDialFamily.
+type PorkbunResolver struct {
+	Client *porkbun.Client
+}
+
+func (r *PorkbunResolver) Name() string {
+	return "porkbun"
+}
+
+func (r *PorkbunResolver) PublicIP(ctx context.Context) (net.IP, error) {
+	ping, err := r.Client.Ping(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ping failed: %w", err)
+	}
+	ip := net.ParseIP(ping.YourIP)
+	if ip == nil {
+		return nil, fmt.Errorf("ping returned an invalid IP address: %q", ping.YourIP)
+	}
+	return ip, nil
+}
+
+// HTTPResolver issues a GET request to a URL that returns the caller's
+// IP address as plain text, such as IpifyV4URL or IfconfigMeURL.
+type HTTPResolver struct {
+	URL string
+	// Network restricts the connection to "tcp4" or "tcp6". Leave empty for either.
+	Network string
+	// Transport is cloned for the request, e.g. to use the proxy settings of
+	// a porkbun.Client. If nil, http.DefaultTransport is used.
+	Transport *http.Transport
+}
+
+func (r *HTTPResolver) Name() string {
+	return r.URL
+}
+
+func (r *HTTPResolver) PublicIP(ctx context.Context) (net.IP, error) {
+	base := r.Transport
+	if base == nil {
+		base = http.DefaultTransport.(*http.Transport)
+	}
+	transport := base.Clone()
+	if r.Network != "" {
+		var d net.Dialer
+		transport.DialContext = func(ctx context.Context, _, addr string) (net.Conn, error) {
+			return d.DialContext(ctx, r.Network, addr)
+		}
+	}
+	client := &http.Client{Transport: transport}
+	defer client.CloseIdleConnections()
+	req, err := http.NewRequestWithContext(ctx, "GET", r.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("GET failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return nil, fmt.Errorf("could not read response body: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("response status %s", resp.Status)
+	}
+	s := strings.TrimSpace(string(body))
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, fmt.Errorf("response is not an IP address: %q", s)
+	}
+	return ip, nil
+}
+
+// Fallback tries each of its resolvers in order and returns the first IP address found.
+type Fallback []IPResolver
+
+func (f Fallback) Name() string {
+	names := make([]string, len(f))
+	for i, r := range f {
+		names[i] = r.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+func (f Fallback) PublicIP(ctx context.Context) (net.IP, error) {
+	var errs []error
+	for _, r := range f {
+		ip, err := r.PublicIP(ctx)
+		if err == nil {
+			return ip, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", r.Name(), err))
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("no IP resolvers configured")
+	}
+	return nil, errors.Join(errs...)
+}
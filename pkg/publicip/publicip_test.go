@@ -0,0 +1,35 @@
+package publicip
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestHTTPResolverUsesTransportProxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		io.WriteString(w, "198.51.100.9\n")
+	}))
+	defer proxy.Close()
+	u, _ := url.Parse(proxy.URL)
+
+	r := &HTTPResolver{
+		URL:       "http://ip.example.invalid/",
+		Transport: &http.Transport{Proxy: http.ProxyURL(u)},
+	}
+	ip, err := r.PublicIP(context.Background())
+	if err != nil {
+		t.Fatalf("PublicIP: %v", err)
+	}
+	if ip.String() != "198.51.100.9" {
+		t.Errorf("PublicIP = %s, want 198.51.100.9", ip)
+	}
+	if proxied != r.URL {
+		t.Errorf("proxy received %q, want %q", proxied, r.URL)
+	}
+}
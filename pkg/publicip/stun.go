@@ -0,0 +1,144 @@
+package publicip
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+)
+
+// STUN message constants, see RFC 5389.
+const (
+	stunBindingRequest  = 0x0001
+	stunBindingResponse = 0x0101
+	stunMagicCookie     = 0x2112A442
+	stunHeaderLen       = 20
+
+	stunAttrMappedAddress    = 0x0001
+	stunAttrXorMappedAddress = 0x0020
+)
+
+// STUNResolver sends a STUN binding request (RFC 5389) to a STUN server
+// and returns the mapped address from its response.
+type STUNResolver struct {
+	// Server is the host:port of the STUN server, e.g. GoogleSTUNServer.
+	Server string
+	// Network is "udp4" or "udp6". Leave empty for either.
+	Network string
+}
+
+func (r *STUNResolver) Name() string {
+	return "stun:" + r.Server
+}
+
+func (r *STUNResolver) PublicIP(ctx context.Context) (net.IP, error) {
+	network := r.Network
+	if network == "" {
+		network = "udp"
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, network, r.Server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(5 * time.Second)
+	}
+	conn.SetDeadline(deadline)
+
+	req := make([]byte, stunHeaderLen)
+	binary.BigEndian.PutUint16(req[0:], stunBindingRequest)
+	binary.BigEndian.PutUint32(req[4:], stunMagicCookie)
+	txID := req[8:20]
+	if _, err := rand.Read(txID); err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write(req); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 1500)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+	return parseSTUNResponse(buf[:n], txID)
+}
+
+func parseSTUNResponse(msg, txID []byte) (net.IP, error) {
+	if len(msg) < stunHeaderLen {
+		return nil, fmt.Errorf("STUN response too short: %d bytes", len(msg))
+	}
+	if t := binary.BigEndian.Uint16(msg[0:]); t != stunBindingResponse {
+		return nil, fmt.Errorf("unexpected STUN message type 0x%04x", t)
+	}
+	if !bytes.Equal(msg[8:20], txID) {
+		return nil, fmt.Errorf("STUN transaction ID mismatch")
+	}
+	length := int(binary.BigEndian.Uint16(msg[2:]))
+	if stunHeaderLen+length > len(msg) {
+		return nil, fmt.Errorf("truncated STUN response")
+	}
+	var mapped net.IP
+	attrs := msg[stunHeaderLen : stunHeaderLen+length]
+	for len(attrs) >= 4 {
+		typ := binary.BigEndian.Uint16(attrs[0:])
+		alen := int(binary.BigEndian.Uint16(attrs[2:]))
+		if 4+alen > len(attrs) {
+			return nil, fmt.Errorf("truncated STUN attribute")
+		}
+		val := attrs[4 : 4+alen]
+		switch typ {
+		case stunAttrXorMappedAddress:
+			// The address is XOR'ed with the magic cookie and the transaction ID.
+			ip, err := stunAddress(val)
+			if err != nil {
+				return nil, err
+			}
+			for i := range ip {
+				ip[i] ^= msg[4+i]
+			}
+			return ip, nil
+		case stunAttrMappedAddress:
+			ip, err := stunAddress(val)
+			if err != nil {
+				return nil, err
+			}
+			mapped = ip
+		}
+		// Attributes are padded to a multiple of 4 bytes.
+		next := 4 + (alen+3)&^3
+		if next > len(attrs) {
+			break
+		}
+		attrs = attrs[next:]
+	}
+	if mapped != nil {
+		return mapped, nil
+	}
+	return nil, fmt.Errorf("STUN response contains no mapped address")
+}
+
+// stunAddress returns a copy of the IP address of a (XOR-)MAPPED-ADDRESS attribute value.
+func stunAddress(val []byte) (net.IP, error) {
+	if len(val) < 4 {
+		return nil, fmt.Errorf("invalid STUN address attribute")
+	}
+	var n int
+	switch val[1] {
+	case 0x01:
+		n = net.IPv4len
+	case 0x02:
+		n = net.IPv6len
+	default:
+		return nil, fmt.Errorf("unknown STUN address family 0x%02x", val[1])
+	}
+	if len(val) < 4+n {
+		return nil, fmt.Errorf("invalid STUN address attribute")
+	}
+	return net.IP(bytes.Clone(val[4 : 4+n])), nil
+}
@@ -0,0 +1,73 @@
+package zone
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/dnswlt/porkbun/pkg/api"
+)
+
+// maxTXTString is the maximum length of a character-string in a TXT record.
+const maxTXTString = 255
+
+// quoteTXT returns content as one or more quoted character-strings.
+func quoteTXT(content string) string {
+	var parts []string
+	for {
+		n := min(len(content), maxTXTString)
+		parts = append(parts, api.QuoteCharString(content[:n]))
+		content = content[n:]
+		if content == "" {
+			break
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// WriteZoneFile writes records in BIND zone file syntax to w, such that
+// ParseZoneFile with the same origin returns equivalent records.
+// origin is the domain of the zone, e.g. "example.com".
+// Names are written relative to origin.
+func WriteZoneFile(w io.Writer, origin string, records []*api.Record) error {
+	domain := strings.TrimSuffix(strings.ToLower(origin), ".")
+	owner := func(name string) string {
+		name = strings.TrimSuffix(strings.ToLower(name), ".")
+		if name == domain {
+			return "@"
+		}
+		if sub, ok := strings.CutSuffix(name, "."+domain); ok {
+			return sub
+		}
+		return absolute(name)
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "$ORIGIN %s.\n", domain)
+	for _, r := range records {
+		var rdata string
+		switch r.Type {
+		case "CNAME", "NS", "ALIAS":
+			rdata = absolute(r.Content)
+		case "MX":
+			rdata = fmt.Sprintf("%s %s", r.Prio, absolute(r.Content))
+		case "SRV":
+			// Porkbun stores "weight port target" and the priority separately.
+			fields := strings.Fields(r.Content)
+			if len(fields) != 3 {
+				return fmt.Errorf("invalid SRV record content for %s: %q", r.Name, r.Content)
+			}
+			rdata = fmt.Sprintf("%s %s %s %s", r.Prio, fields[0], fields[1], absolute(fields[2]))
+		case "TXT":
+			rdata = quoteTXT(r.Content)
+		default:
+			rdata = r.Content
+		}
+		ttl := r.TTL
+		if ttl == "" {
+			ttl = "600"
+		}
+		fmt.Fprintf(bw, "%s\t%s\tIN\t%s\t%s\n", owner(r.Name), ttl, r.Type, rdata)
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,405 @@
+// Package zone converts between BIND zone files and Porkbun DNS records.
+package zone
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/dnswlt/porkbun/pkg/api"
+)
+
+// token is a field of a zone file line.
+type token struct {
+	text   string
+	quoted bool
+}
+
+// line is a logical line of a zone file, with parenthesized continuations joined.
+type line struct {
+	num int
+	// indented is true if the line starts with whitespace, i.e. has no owner name.
+	indented bool
+	tokens   []token
+}
+
+// lex splits the zone file into logical lines.
+func lex(r io.Reader) ([]line, error) {
+	br := bufio.NewReader(r)
+	var lines []line
+	cur := line{num: 1}
+	num := 1
+	depth := 0
+	atStart := true
+	var tok strings.Builder
+	inTok, inQuote, escaped := false, false, false
+	flush := func() {
+		if inTok {
+			cur.tokens = append(cur.tokens, token{text: tok.String()})
+			tok.Reset()
+			inTok = false
+		}
+	}
+	for {
+		c, err := br.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if inQuote {
+			switch {
+			case escaped:
+				escaped = false
+				if isDigit(c) {
+					// \DDD is the byte with decimal value DDD.
+					d, err := br.Peek(2)
+					if err != nil || !isDigit(d[0]) || !isDigit(d[1]) {
+						return nil, fmt.Errorf("line %d: invalid escape sequence: \\DDD requires three digits", num)
+					}
+					v := int(c-'0')*100 + int(d[0]-'0')*10 + int(d[1]-'0')
+					if v > 255 {
+						return nil, fmt.Errorf("line %d: invalid escape sequence \\%c%s: value exceeds 255", num, c, d)
+					}
+					br.Discard(2)
+					c = byte(v)
+				}
+				tok.WriteByte(c)
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				cur.tokens = append(cur.tokens, token{text: tok.String(), quoted: true})
+				tok.Reset()
+				inQuote = false
+			default:
+				if c == '\n' {
+					num++
+				}
+				tok.WriteByte(c)
+			}
+			continue
+		}
+		if atStart {
+			atStart = false
+			cur.indented = c == ' ' || c == '\t'
+		}
+		switch c {
+		case ';':
+			flush()
+			// Skip the comment, but not the newline.
+			for {
+				b, err := br.ReadByte()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					return nil, err
+				}
+				if b == '\n' {
+					br.UnreadByte()
+					break
+				}
+			}
+		case '"':
+			flush()
+			inQuote = true
+		case '(':
+			flush()
+			depth++
+		case ')':
+			flush()
+			if depth == 0 {
+				return nil, fmt.Errorf("line %d: unbalanced parentheses", num)
+			}
+			depth--
+		case ' ', '\t', '\r':
+			flush()
+		case '\n':
+			flush()
+			num++
+			if depth == 0 {
+				if len(cur.tokens) > 0 {
+					lines = append(lines, cur)
+				}
+				cur = line{num: num}
+				atStart = true
+			}
+		default:
+			tok.WriteByte(c)
+			inTok = true
+		}
+	}
+	if inQuote {
+		return nil, fmt.Errorf("line %d: unterminated quoted string", cur.num)
+	}
+	if depth > 0 {
+		return nil, fmt.Errorf("line %d: unbalanced parentheses", cur.num)
+	}
+	flush()
+	if len(cur.tokens) > 0 {
+		lines = append(lines, cur)
+	}
+	return lines, nil
+}
+
+// parseTTL parses a TTL in seconds or with BIND unit suffixes, e.g. "1h30m".
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func parseTTL(s string) (int, bool) {
+	if n, err := strconv.Atoi(s); err == nil {
+		return n, n >= 0
+	}
+	total, n, digits := 0, 0, 0
+	for _, c := range strings.ToLower(s) {
+		if c >= '0' && c <= '9' {
+			n = n*10 + int(c-'0')
+			digits++
+			continue
+		}
+		if digits == 0 {
+			return 0, false
+		}
+		switch c {
+		case 's':
+		case 'm':
+			n *= 60
+		case 'h':
+			n *= 3600
+		case 'd':
+			n *= 86400
+		case 'w':
+			n *= 7 * 86400
+		default:
+			return 0, false
+		}
+		total += n
+		n, digits = 0, 0
+	}
+	if digits > 0 {
+		return 0, false
+	}
+	return total, true
+}
+
+func absolute(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
+
+// parser holds the state of ParseZoneFile.
+type parser struct {
+	// domain is the absolute name of the zone, e.g. "example.com.".
+	domain     string
+	origin     string
+	defaultTTL string
+	lastOwner  string
+	lastTTL    string
+}
+
+// fqdn returns the absolute name of name, relative to the current origin.
+func (p *parser) fqdn(name string) string {
+	switch {
+	case name == "@":
+		return p.origin
+	case strings.HasSuffix(name, "."):
+		return name
+	case p.origin == ".":
+		return name + "."
+	}
+	return name + "." + p.origin
+}
+
+// subdomain returns name relative to the zone's domain.
+func (p *parser) subdomain(name string) (string, error) {
+	abs := strings.ToLower(p.fqdn(name))
+	if abs == p.domain {
+		return "", nil
+	}
+	if sub, ok := strings.CutSuffix(abs, "."+p.domain); ok {
+		return sub, nil
+	}
+	return "", fmt.Errorf("name %s is outside of zone %s", abs, p.domain)
+}
+
+// target returns a domain name in rdata in the form Porkbun expects:
+// fully qualified, without the trailing dot.
+func (p *parser) target(name string) string {
+	if name == "." {
+		return name
+	}
+	return strings.TrimSuffix(p.fqdn(name), ".")
+}
+
+func isClass(s string) bool {
+	switch strings.ToUpper(s) {
+	case "IN", "CH", "HS", "CS":
+		return true
+	}
+	return false
+}
+
+// ParseZoneFile parses the BIND zone file r and returns its records as requests for
+// Client.CreateRecord. origin is the domain of the zone, e.g. "example.com", and
+// is also the initial $ORIGIN. Names are converted to subdomains relative to origin.
+//
+// The directives $TTL and $ORIGIN are supported, as are multi-line records
+// in parentheses. SOA records are skipped, since Porkbun manages them.
+// TXT records consisting of multiple strings are concatenated.
+func ParseZoneFile(r io.Reader, origin string) ([]api.UpdateRequest, error) {
+	lines, err := lex(r)
+	if err != nil {
+		return nil, err
+	}
+	domain := absolute(strings.ToLower(origin))
+	p := &parser{domain: domain, origin: domain}
+	var reqs []api.UpdateRequest
+	for _, l := range lines {
+		req, err := p.parseLine(l)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", l.num, err)
+		}
+		if req != nil {
+			reqs = append(reqs, *req)
+		}
+	}
+	return reqs, nil
+}
+
+// parseLine parses a single logical line. It returns nil for directives and skipped records.
+func (p *parser) parseLine(l line) (*api.UpdateRequest, error) {
+	toks := l.tokens
+	switch strings.ToUpper(toks[0].text) {
+	case "$TTL":
+		if len(toks) < 2 {
+			return nil, fmt.Errorf("$TTL requires a value")
+		}
+		ttl, ok := parseTTL(toks[1].text)
+		if !ok {
+			return nil, fmt.Errorf("invalid $TTL %q", toks[1].text)
+		}
+		p.defaultTTL = strconv.Itoa(ttl)
+		return nil, nil
+	case "$ORIGIN":
+		if len(toks) < 2 {
+			return nil, fmt.Errorf("$ORIGIN requires a value")
+		}
+		p.origin = strings.ToLower(p.fqdn(toks[1].text))
+		return nil, nil
+	case "$INCLUDE", "$GENERATE":
+		return nil, fmt.Errorf("unsupported directive %s", toks[0].text)
+	}
+
+	owner := p.lastOwner
+	if !l.indented {
+		owner = p.fqdn(toks[0].text)
+		toks = toks[1:]
+	}
+	if owner == "" {
+		return nil, fmt.Errorf("record without owner name")
+	}
+	p.lastOwner = owner
+
+	ttl := ""
+	for len(toks) > 0 && !toks[0].quoted {
+		if n, ok := parseTTL(toks[0].text); ok {
+			ttl = strconv.Itoa(n)
+		} else if !isClass(toks[0].text) {
+			break
+		}
+		toks = toks[1:]
+	}
+	if len(toks) == 0 {
+		return nil, fmt.Errorf("missing record type")
+	}
+	switch {
+	case ttl != "":
+		p.lastTTL = ttl
+	case p.defaultTTL != "":
+		ttl = p.defaultTTL
+	default:
+		ttl = p.lastTTL
+	}
+
+	typ := strings.ToUpper(toks[0].text)
+	rdata := toks[1:]
+	if typ == "SOA" {
+		return nil, nil
+	}
+	if !api.ValidRecordType(typ) {
+		return nil, fmt.Errorf("unsupported record type %s", typ)
+	}
+	name, err := p.subdomain(owner)
+	if err != nil {
+		return nil, err
+	}
+	req := &api.UpdateRequest{Name: name, Type: typ, TTL: ttl}
+	need := func(n int) error {
+		if len(rdata) < n {
+			return fmt.Errorf("%s record requires %d fields, got %d", typ, n, len(rdata))
+		}
+		return nil
+	}
+	switch typ {
+	case "A", "AAAA":
+		if err := need(1); err != nil {
+			return nil, err
+		}
+		ip := net.ParseIP(rdata[0].text)
+		if ip == nil || (ip.To4() != nil) != (typ == "A") {
+			return nil, fmt.Errorf("invalid %s address %q", typ, rdata[0].text)
+		}
+		req.Content = rdata[0].text
+	case "CNAME", "NS", "ALIAS":
+		if err := need(1); err != nil {
+			return nil, err
+		}
+		req.Content = p.target(rdata[0].text)
+	case "MX":
+		if err := need(2); err != nil {
+			return nil, err
+		}
+		req.Prio = rdata[0].text
+		req.Content = p.target(rdata[1].text)
+	case "SRV":
+		if err := need(4); err != nil {
+			return nil, err
+		}
+		req.Prio = rdata[0].text
+		req.Content = fmt.Sprintf("%s %s %s", rdata[1].text, rdata[2].text, p.target(rdata[3].text))
+	case "TXT":
+		if err := need(1); err != nil {
+			return nil, err
+		}
+		var sb strings.Builder
+		for _, t := range rdata {
+			sb.WriteString(t.text)
+		}
+		req.Content = sb.String()
+	case "CAA":
+		if err := need(3); err != nil {
+			return nil, err
+		}
+		req.Content = fmt.Sprintf("%s %s %s", rdata[0].text, rdata[1].text, api.QuoteCharString(rdata[2].text))
+	default:
+		if err := need(1); err != nil {
+			return nil, err
+		}
+		fields := make([]string, len(rdata))
+		for i, t := range rdata {
+			fields[i] = t.text
+			if t.quoted {
+				fields[i] = api.QuoteCharString(t.text)
+			}
+		}
+		req.Content = strings.Join(fields, " ")
+	}
+	return req, nil
+}
@@ -0,0 +1,77 @@
+package zone
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dnswlt/porkbun/pkg/api"
+)
+
+func TestParseZoneFileEscapes(t *testing.T) {
+	tests := []struct {
+		rdata string
+		want  string
+	}{
+		{`"a \059"`, "a ;"},
+		{`"\065\066C"`, "ABC"},
+		{`"say \"hi\""`, `say "hi"`},
+		{`"back\\slash"`, `back\slash`},
+		{`"\;"`, ";"},
+	}
+	for _, tc := range tests {
+		recs, err := ParseZoneFile(strings.NewReader("www 3600 IN TXT "+tc.rdata+"\n"), "example.com")
+		if err != nil {
+			t.Errorf("ParseZoneFile(%s): %v", tc.rdata, err)
+			continue
+		}
+		if len(recs) != 1 || recs[0].Content != tc.want {
+			t.Errorf("ParseZoneFile(%s) = %+v, want content %q", tc.rdata, recs, tc.want)
+		}
+	}
+}
+
+func TestParseZoneFileInvalidEscapes(t *testing.T) {
+	for _, rdata := range []string{`"\05"`, `"\256"`, `"\0x1"`} {
+		if _, err := ParseZoneFile(strings.NewReader("www TXT "+rdata+"\n"), "example.com"); err == nil {
+			t.Errorf("ParseZoneFile(%s) succeeded, want error", rdata)
+		}
+	}
+}
+
+func TestZoneFileRoundTrip(t *testing.T) {
+	const zone = "www 600 IN CAA 0 issue \"ca\\233\\009.example\"\n" +
+		"txt 600 IN TXT \"caf\\233 \\\"x\\\" \\\\ end\"\n" +
+		"utf8 600 IN TXT \"bücher\"\n"
+	recs, err := ParseZoneFile(strings.NewReader(zone), "example.com")
+	if err != nil {
+		t.Fatalf("ParseZoneFile: %v", err)
+	}
+	want := []string{`0 issue "ca\233\009.example"`, "caf\xe9 \"x\" \\ end", "bücher"}
+	var records []*api.Record
+	for i, r := range recs {
+		if r.Content != want[i] {
+			t.Errorf("record %d: content %q, want %q", i, r.Content, want[i])
+		}
+		records = append(records, &api.Record{Name: r.Name + ".example.com", Type: r.Type, Content: r.Content, TTL: r.TTL})
+	}
+
+	var buf strings.Builder
+	if err := WriteZoneFile(&buf, "example.com", records); err != nil {
+		t.Fatalf("WriteZoneFile: %v", err)
+	}
+	if strings.Contains(buf.String(), `\x`) || strings.Contains(buf.String(), `\u`) {
+		t.Errorf("WriteZoneFile wrote Go escapes:\n%s", buf.String())
+	}
+	again, err := ParseZoneFile(strings.NewReader(buf.String()), "example.com")
+	if err != nil {
+		t.Fatalf("ParseZoneFile of written zone: %v\n%s", err, buf.String())
+	}
+	for i, r := range again {
+		if i < len(recs) && r != recs[i] {
+			t.Errorf("round trip of record %d: %+v, want %+v", i, r, recs[i])
+		}
+	}
+	if len(again) != len(recs) {
+		t.Errorf("round trip returned %d records, want %d", len(again), len(recs))
+	}
+}